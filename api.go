@@ -0,0 +1,644 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// The JSON API serves the same data as the commands, all under /api/v1
+type apiServer struct {
+	s *state
+}
+
+type apiHandler func(w http.ResponseWriter, r *http.Request, user database.User)
+
+// Runs the JSON API, and the aggregator too when -agg is given. Requests
+// are made as the user whose API token they send
+func handlerServe(s *state, cmd command) error {
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveCmd.String("addr", "localhost:8080", "Address to listen on")
+	agg := serveCmd.Duration("agg", 0, "Also collect feeds this often, such as 10m")
+	serveCmd.Parse(cmd.args)
+
+	if *agg > 0 {
+		fmt.Printf("Collecting feeds every %v\n", *agg)
+		go func() {
+			ticker := time.NewTicker(*agg)
+			for ; ; <-ticker.C {
+				_ = scrapeFeeds(s)
+			}
+		}()
+	}
+
+	api := &apiServer{s: s}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Printf("Serving the API on http://%v/api/v1/\n", *addr)
+	return server.ListenAndServe()
+}
+
+func (api *apiServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/openapi.json", api.getOpenAPI)
+	mux.HandleFunc("GET /api/v1/users", api.middlewareToken(scopeRead, api.listUsers))
+	mux.HandleFunc("GET /api/v1/feeds", api.middlewareToken(scopeRead, api.listFeeds))
+	mux.HandleFunc("GET /api/v1/follows", api.middlewareToken(scopeRead, api.listFollows))
+	mux.HandleFunc("POST /api/v1/follows", api.middlewareToken(scopeWrite, api.createFollow))
+	mux.HandleFunc("GET /api/v1/posts", api.middlewareToken(scopeRead, api.listPosts))
+	mux.HandleFunc("GET /api/v1/posts/{id}", api.middlewareToken(scopeRead, api.getPost))
+	mux.HandleFunc("PUT /api/v1/posts/{id}/read", api.middlewareToken(scopeWrite, api.markRead))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}/read", api.middlewareToken(scopeWrite, api.markUnread))
+	mux.HandleFunc("PUT /api/v1/posts/{id}/star", api.middlewareToken(scopeWrite, api.star))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}/star", api.middlewareToken(scopeWrite, api.unstar))
+	mux.HandleFunc("GET /api/v1/saved", api.middlewareToken(scopeRead, api.listSaved))
+	mux.HandleFunc("GET /api/v1/feed", api.middlewareFeedToken(api.publish))
+	api.readerRoutes(mux)
+	api.feverRoutes(mux)
+	return mux
+}
+
+// Like middlewareLoggedIn, but the user comes from the API token sent as
+// a bearer token
+func (api *apiServer) middlewareToken(scope string, handler apiHandler) http.HandlerFunc {
+	return api.checkToken(scope, false, handler)
+}
+
+// Feed readers can't set headers, so the feed also takes a read token as
+// access_token. Nothing else does, tokens in urls end up in logs
+func (api *apiServer) middlewareFeedToken(handler apiHandler) http.HandlerFunc {
+	return api.checkToken(scopeRead, true, handler)
+}
+
+func (api *apiServer) checkToken(scope string, allowQuery bool, handler apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok && allowQuery {
+			token = r.URL.Query().Get("access_token")
+		}
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator"`)
+			writeError(w, http.StatusUnauthorized, "Missing API token")
+			return
+		}
+
+		row, err := api.s.db.GetUserByAPIToken(ctx, hashToken(strings.TrimSpace(token)))
+		if errors.Is(err, sql.ErrNoRows) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "Invalid API token")
+			return
+		} else if err != nil {
+			serverError(w, err)
+			return
+		}
+		if scope == scopeWrite && row.Scope != scopeWrite {
+			writeError(w, http.StatusForbidden, "API token is read only")
+			return
+		}
+
+		err = api.s.db.MarkAPITokenUsed(ctx, database.MarkAPITokenUsedParams{
+			ID:         row.TokenID,
+			LastUsedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Error marking token used: %v", err)
+		}
+
+		handler(w, r, database.User{
+			ID:           row.ID,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			Name:         row.Name,
+			PasswordHash: row.PasswordHash,
+			FeverApiKey:  row.FeverApiKey,
+		})
+	}
+}
+
+type apiUser struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	Title         string     `json:"title,omitempty"`
+	SiteLink      string     `json:"site_link,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type apiFollow struct {
+	FeedID      uuid.UUID `json:"feed_id"`
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	SiteLink    string    `json:"site_link,omitempty"`
+	Folder      string    `json:"folder,omitempty"`
+	UnreadCount int64     `json:"unread_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type apiPost struct {
+	ID          uuid.UUID  `json:"id"`
+	ShortID     string     `json:"short_id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedName    string     `json:"feed_name"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Read        bool       `json:"read"`
+	Starred     bool       `json:"starred"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+}
+
+type apiPostList struct {
+	Posts []apiPost `json:"posts"`
+	// Pass as after to get the next page, empty on the last page
+	Next string `json:"next,omitempty"`
+}
+
+func (api *apiServer) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func (api *apiServer) listUsers(w http.ResponseWriter, r *http.Request, user database.User) {
+	users, err := api.s.db.GetAllUsers(r.Context())
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	result := []apiUser{}
+	for _, u := range users {
+		result = append(result, apiUser{ID: u.ID, Name: u.Name, CreatedAt: u.CreatedAt})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (api *apiServer) listFeeds(w http.ResponseWriter, r *http.Request, user database.User) {
+	feeds, err := api.s.db.GetFeeds(r.Context())
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	result := []apiFeed{}
+	for _, feed := range feeds {
+		result = append(result, apiFeed{
+			ID:            feed.ID,
+			Name:          feed.Name,
+			URL:           feed.Url,
+			Title:         feed.Title.String,
+			SiteLink:      feed.SiteLink.String,
+			Description:   feed.Description.String,
+			LastFetchedAt: timePointer(feed.LastFetchedAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (api *apiServer) listFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := api.s.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	result := []apiFollow{}
+	for _, follow := range follows {
+		result = append(result, apiFollow{
+			FeedID:      follow.FeedID,
+			Name:        follow.FeedName,
+			URL:         follow.FeedUrl,
+			SiteLink:    follow.SiteLink.String,
+			Folder:      follow.FolderName.String,
+			UnreadCount: follow.UnreadCount,
+			CreatedAt:   follow.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Follows a feed by url, adding the feed first if gator doesn't know it
+func (api *apiServer) createFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+
+	var body struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.URL == "" {
+		writeError(w, http.StatusBadRequest, "Body must be JSON with a url")
+		return
+	}
+
+	feed, err := api.s.db.GetFeedUrl(ctx, body.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := body.Name
+		if name == "" {
+			name = body.URL
+		}
+		feed, err = api.s.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+			Url:       body.URL,
+			UserID:    user.ID,
+		})
+	}
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+
+	follow, err := api.s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		Title:     nullString(body.Name),
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		writeError(w, http.StatusConflict, "Already following "+body.URL)
+		return
+	} else if err != nil {
+		serverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, apiFollow{
+		FeedID:    follow.FeedID,
+		Name:      follow.FeedName,
+		URL:       feed.Url,
+		SiteLink:  feed.SiteLink.String,
+		CreatedAt: follow.CreatedAt,
+	})
+}
+
+// Takes the same filters as browse, as query parameters
+func (api *apiServer) listPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	limit, err := queryLimit(query.Get("limit"), 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params := database.GetPostsForUserParams{
+		UserID:   user.ID,
+		Folder:   nullString(query.Get("folder")),
+		Feed:     nullString(query.Get("feed")),
+		Author:   nullString(query.Get("author")),
+		Category: nullString(query.Get("category")),
+		Keyword:  nullString(query.Get("keyword")),
+		Limit:    int32(limit),
+	}
+
+	if read := query.Get("read"); read != "" {
+		value, err := strconv.ParseBool(read)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "read must be true or false")
+			return
+		}
+		params.Read = sql.NullBool{Bool: value, Valid: true}
+	}
+	if search := query.Get("search"); search != "" {
+		searchQuery, err := savedSearchQuery(ctx, api.s, user, search)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		params.Search = sql.NullString{String: searchQuery, Valid: true}
+	}
+	for _, param := range []struct {
+		name  string
+		value *sql.NullTime
+	}{{"since", &params.Since}, {"until", &params.Until}} {
+		if value := query.Get(param.name); value != "" {
+			date, err := parseDateArg(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			*param.value = sql.NullTime{Time: date, Valid: true}
+		}
+	}
+	if after := query.Get("after"); after != "" {
+		post, ok := api.findPost(w, ctx, user, after)
+		if !ok {
+			return
+		}
+		params.AfterDate = sql.NullTime{Time: postDate(post), Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: post.ID, Valid: true}
+	}
+
+	posts, err := api.s.db.GetPostsForUser(ctx, params)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	result := apiPostList{Posts: []apiPost{}}
+	for _, post := range posts {
+		result.Posts = append(result.Posts, apiPost{
+			ID:          post.ID,
+			ShortID:     shortID(post.ID),
+			Title:       post.Title,
+			URL:         post.Url,
+			FeedID:      post.FeedID,
+			FeedName:    post.FeedName,
+			PublishedAt: timePointer(post.PublishedAt),
+			CreatedAt:   post.CreatedAt,
+			Read:        post.Read,
+			Starred:     post.Saved,
+			Description: post.Description.String,
+		})
+	}
+	if len(posts) == limit {
+		result.Next = posts[len(posts)-1].ID.String()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (api *apiServer) getPost(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+
+	post, ok := api.findPost(w, ctx, user, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	feedName, err := api.s.db.GetFeedNameForUser(ctx, database.GetFeedNameForUserParams{
+		UserID: user.ID,
+		FeedID: post.FeedID,
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	postState, err := api.s.db.GetPostState(ctx, database.GetPostStateParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiPost{
+		ID:          post.ID,
+		ShortID:     shortID(post.ID),
+		Title:       post.Title,
+		URL:         post.Url,
+		FeedID:      post.FeedID,
+		FeedName:    feedName,
+		PublishedAt: timePointer(post.PublishedAt),
+		CreatedAt:   post.CreatedAt,
+		Read:        postState.Read,
+		Starred:     postState.Saved,
+		Description: post.Description.String,
+		Content:     post.Content.String,
+	})
+}
+
+func (api *apiServer) markRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findPost(w, r.Context(), user, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	err := api.s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) markUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findPost(w, r.Context(), user, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	_, err := api.s.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Starred posts are the same as saved posts in the commands
+func (api *apiServer) star(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findPost(w, r.Context(), user, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	err := api.s.db.SavePost(r.Context(), database.SavePostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *apiServer) unstar(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := api.findPost(w, r.Context(), user, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	_, err := api.s.db.UnsavePost(r.Context(), database.UnsavePostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Newest saves first, pages continue after the last post of the one before
+func (api *apiServer) listSaved(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	limit, err := queryLimit(query.Get("limit"), 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	params := database.GetSavedPostsForUserParams{
+		UserID: user.ID,
+		Limit:  int32(limit),
+	}
+	if after := query.Get("after"); after != "" {
+		post, ok := api.findPost(w, ctx, user, after)
+		if !ok {
+			return
+		}
+		params.AfterID = uuid.NullUUID{UUID: post.ID, Valid: true}
+	}
+
+	saved, err := api.s.db.GetSavedPostsForUser(ctx, params)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	result := apiPostList{Posts: []apiPost{}}
+	for _, post := range saved {
+		result.Posts = append(result.Posts, apiPost{
+			ID:          post.ID,
+			ShortID:     shortID(post.ID),
+			Title:       post.Title,
+			URL:         post.Url,
+			FeedName:    post.FeedName,
+			PublishedAt: timePointer(post.PublishedAt),
+			Starred:     true,
+		})
+	}
+	if len(saved) == limit {
+		result.Next = saved[len(saved)-1].ID.String()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// The publish command over HTTP, so other readers can subscribe to gator
+func (api *apiServer) publish(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "rss"
+	}
+	limit, err := queryLimit(query.Get("limit"), 50)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The token mustn't end up in the feed's self link
+	selfQuery := r.URL.Query()
+	selfQuery.Del("access_token")
+	selfURL := requestScheme(r) + "://" + r.Host + r.URL.Path
+	if len(selfQuery) > 0 {
+		selfURL += "?" + selfQuery.Encode()
+	}
+	data, err := publishFeed(r.Context(), api.s, user, format, publishOptions{
+		Folder: query.Get("folder"),
+		Search: query.Get("search"),
+		Limit:  limit,
+		URL:    selfURL,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/"+format+"+xml; charset=utf-8")
+	w.Write(data)
+}
+
+// Writes the error response itself when the post can't be found. Only
+// posts from feeds the user follows or has saved are found, others are a 404
+func (api *apiServer) findPost(w http.ResponseWriter, ctx context.Context, user database.User, ref string) (database.Post, bool) {
+	post, _, err := lookupPost(ctx, api.s, user, ref, true)
+	if errors.Is(err, errPostNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return database.Post{}, false
+	} else if errors.Is(err, errAmbiguousPost) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return database.Post{}, false
+	} else if err != nil {
+		serverError(w, err)
+		return database.Post{}, false
+	}
+	return post, true
+}
+
+// Behind a proxy that ends TLS the request itself is plain http, so the
+// proxy's X-Forwarded-Proto is used when there is one
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto == "https" || proto == "http" {
+		return proto
+	}
+	return "http"
+}
+
+func queryLimit(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > 100 {
+		return 0, fmt.Errorf("limit must be a number from 1 to 100")
+	}
+	return limit, nil
+}
+
+func timePointer(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// The details are logged rather than sent back to the client
+func serverError(w http.ResponseWriter, err error) {
+	log.Printf("Error handling request: %v", err)
+	writeError(w, http.StatusInternalServerError, "Internal server error")
+}
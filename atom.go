@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Atom feeds are converted into an RSSFeed after unmarshalling so the rest
+// of gator only has to deal with one shape of feed
+type AtomFeed struct {
+	XMLName   xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string       `xml:"id,omitempty"`
+	Title     AtomText     `xml:"title"`
+	Subtitle  AtomText     `xml:"subtitle"`
+	Links     []AtomLink   `xml:"link"`
+	Updated   string       `xml:"updated"`
+	Authors   []AtomPerson `xml:"author"`
+	Generator string       `xml:"generator,omitempty"`
+	Rights    AtomText     `xml:"rights"`
+	Logo      string       `xml:"logo,omitempty"`
+	Icon      string       `xml:"icon,omitempty"`
+	Entry     []AtomEntry  `xml:"entry"`
+}
+
+type AtomEntry struct {
+	Title      AtomText       `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []AtomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+// Text constructs can be plain text, escaped html or inline xhtml
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// Text gator writes is always escaped, empty text constructs are left out
+func (t AtomText) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if t.Text == "" {
+		return nil
+	}
+	if t.Type != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: t.Type})
+	}
+	return e.EncodeElement(t.Text, start)
+}
+
+// The alternate link points at the html page, rel defaults to alternate
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+func atomToRSS(atom *AtomFeed) *RSSFeed {
+	feed := &RSSFeed{}
+	feed.Channel.Title = atom.Title.String()
+	feed.Channel.Link = alternateLink(atom.Links)
+	feed.Channel.Description = atom.Subtitle.String()
+	feed.Channel.Generator = strings.TrimSpace(atom.Generator)
+	feed.Channel.Copyright = atom.Rights.String()
+	feed.Channel.LastBuildDate = atom.Updated
+	feed.Channel.Image.URL = atom.Logo
+	if feed.Channel.Image.URL == "" {
+		feed.Channel.Image.URL = atom.Icon
+	}
+
+	for _, entry := range atom.Entry {
+		item := RSSItem{
+			Title:       entry.Title.String(),
+			Link:        alternateLink(entry.Links),
+			Description: entry.Summary.String(),
+			Content:     entry.Content.String(),
+			PubDate:     entry.Published,
+		}
+		if item.PubDate == "" {
+			item.PubDate = entry.Updated
+		}
+		for _, author := range entry.Authors {
+			if author.Name != "" {
+				item.Creators = append(item.Creators, author.Name)
+			} else if author.Email != "" {
+				item.Creators = append(item.Creators, author.Email)
+			}
+		}
+		for _, category := range entry.Categories {
+			if category.Label != "" {
+				item.Categories = append(item.Categories, category.Label)
+			} else if category.Term != "" {
+				item.Categories = append(item.Categories, category.Term)
+			}
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL:    link.Href,
+					Length: link.Length,
+					Type:   link.Type,
+				})
+			}
+		}
+		feed.Channel.Item = append(feed.Channel.Item, item)
+	}
+	return feed
+}
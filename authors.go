@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Names from <author>, <dc:creator> and Atom <author>, without duplicates
+func itemAuthors(item RSSItem) []string {
+	var names []string
+	if item.Author != "" {
+		names = append(names, authorName(item.Author))
+	}
+	for _, creator := range item.Creators {
+		names = append(names, strings.TrimSpace(creator))
+	}
+	return uniqueNames(names)
+}
+
+// RSS wants an email address for <author>, often written as
+// "jane@example.com (Jane Doe)", the name is more useful when there is one
+func authorName(author string) string {
+	author = strings.TrimSpace(author)
+	address, err := mail.ParseAddress(author)
+	if err == nil && address.Name != "" {
+		return address.Name
+	}
+	start := strings.Index(author, "(")
+	end := strings.LastIndex(author, ")")
+	if start >= 0 && end > start+1 {
+		return strings.TrimSpace(author[start+1 : end])
+	}
+	return author
+}
+
+func itemCategories(item RSSItem) []string {
+	var names []string
+	for _, category := range item.Categories {
+		names = append(names, strings.TrimSpace(category))
+	}
+	return uniqueNames(names)
+}
+
+// Drops empty names and repeats, ignoring case
+func uniqueNames(names []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+func savePostAuthors(ctx context.Context, s *state, postID uuid.UUID, item RSSItem) error {
+	for _, name := range itemAuthors(item) {
+		authorID, err := s.db.CreateAuthor(ctx, database.CreateAuthorParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+		})
+		if err != nil {
+			fmt.Println("Error saving author")
+			return err
+		}
+		err = s.db.CreatePostAuthor(ctx, database.CreatePostAuthorParams{
+			PostID:   postID,
+			AuthorID: authorID,
+		})
+		if err != nil {
+			fmt.Println("Error linking author to post")
+			return err
+		}
+	}
+	return nil
+}
+
+func savePostCategories(ctx context.Context, s *state, postID uuid.UUID, item RSSItem) error {
+	for _, name := range itemCategories(item) {
+		categoryID, err := s.db.CreateCategory(ctx, database.CreateCategoryParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+		})
+		if err != nil {
+			fmt.Println("Error saving category")
+			return err
+		}
+		err = s.db.CreatePostCategory(ctx, database.CreatePostCategoryParams{
+			PostID:     postID,
+			CategoryID: categoryID,
+		})
+		if err != nil {
+			fmt.Println("Error linking category to post")
+			return err
+		}
+	}
+	return nil
+}
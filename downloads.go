@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+var errQuotaExceeded = errors.New("download quota exceeded")
+
+// Prunes files beyond the per feed retention, then downloads what is missing
+// A limit of zero downloads everything that is missing
+func downloadEnclosures(ctx context.Context, s *state, filter database.GetEnclosuresForDownloadParams, limit int) error {
+	dir, err := s.config.DownloadPath()
+	if err != nil {
+		fmt.Println("Error getting download directory")
+		return err
+	}
+
+	enclosures, err := s.db.GetEnclosuresForDownload(ctx, filter)
+	if err != nil {
+		fmt.Println("Error getting enclosures to download")
+		return err
+	}
+
+	// Enclosures come back newest first within each feed
+	// Media for posts someone has saved is never pruned or counted
+	var wanted []database.GetEnclosuresForDownloadRow
+	perFeed := make(map[uuid.UUID]int)
+	for _, enclosure := range enclosures {
+		if !enclosure.Saved {
+			perFeed[enclosure.FeedID]++
+		}
+		if !enclosure.Saved && s.config.DownloadRetention > 0 && perFeed[enclosure.FeedID] > s.config.DownloadRetention {
+			if enclosure.LocalPath.Valid {
+				err = pruneEnclosure(ctx, s, enclosure)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if enclosure.LocalPath.Valid {
+			_, err := os.Stat(enclosure.LocalPath.String)
+			if err == nil {
+				continue
+			}
+		}
+		wanted = append(wanted, enclosure)
+	}
+
+	used, err := s.db.GetDownloadedSize(ctx)
+	if err != nil {
+		fmt.Println("Error getting size of downloaded media")
+		return err
+	}
+	quota := s.config.DownloadQuotaMB * 1024 * 1024
+
+	downloaded := 0
+	for _, enclosure := range wanted {
+		if limit > 0 && downloaded >= limit {
+			break
+		}
+		// Feeds often leave the length out, so the quota is also checked
+		// against the response and while the file is written
+		var remaining int64
+		if quota > 0 {
+			remaining = quota - used
+			if remaining <= 0 || enclosure.Length.Int64 > remaining {
+				fmt.Printf("Download quota of %v MB reached, skipping %v\n", s.config.DownloadQuotaMB, enclosure.Title)
+				continue
+			}
+		}
+
+		fmt.Printf("Downloading %v: %v\n", enclosure.FeedName, enclosure.Title)
+		localPath, size, err := downloadEnclosure(ctx, dir, enclosure, remaining)
+		if errors.Is(err, errQuotaExceeded) {
+			fmt.Printf("Download quota of %v MB reached, skipping %v\n", s.config.DownloadQuotaMB, enclosure.Title)
+			continue
+		} else if err != nil {
+			// One bad file shouldn't stop the rest from downloading
+			fmt.Printf("Error downloading %v: %v\n", enclosure.Url, err)
+			continue
+		}
+
+		err = s.db.MarkEnclosureDownloaded(ctx, database.MarkEnclosureDownloadedParams{
+			ID:           enclosure.ID,
+			UpdatedAt:    time.Now(),
+			LocalPath:    sql.NullString{String: localPath, Valid: true},
+			LocalSize:    sql.NullInt64{Int64: size, Valid: true},
+			DownloadedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			fmt.Println("Error recording downloaded media")
+			return err
+		}
+		fmt.Printf("Saved %v (%v)\n", localPath, formatBytes(size))
+		used += size
+		downloaded++
+	}
+	return nil
+}
+
+func pruneEnclosure(ctx context.Context, s *state, enclosure database.GetEnclosuresForDownloadRow) error {
+	err := os.Remove(enclosure.LocalPath.String)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Error removing %v\n", enclosure.LocalPath.String)
+		return err
+	}
+	err = s.db.ClearEnclosureDownload(ctx, database.ClearEnclosureDownloadParams{
+		ID:        enclosure.ID,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Println("Error clearing downloaded media")
+		return err
+	}
+	fmt.Printf("Removed %v\n", enclosure.LocalPath.String)
+	return nil
+}
+
+// Downloads into a .part file first so an interrupted download can be
+// resumed with a Range request the next time round. A maxSize above zero
+// stops the download and removes the .part file once the file gets bigger
+func downloadEnclosure(ctx context.Context, dir string, enclosure database.GetEnclosuresForDownloadRow, maxSize int64) (string, int64, error) {
+	localPath := enclosurePath(dir, enclosure)
+	err := os.MkdirAll(filepath.Dir(localPath), 0755)
+	if err != nil {
+		return "", 0, err
+	}
+	partPath := localPath + ".part"
+
+	var offset int64
+	info, err := os.Stat(partPath)
+	if err == nil {
+		offset = info.Size()
+	}
+
+	request, err := newRequest(ctx, enclosure.Url)
+	if err != nil {
+		return "", 0, err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// The server ignored the range, start again from the beginning
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset == 0 {
+			return "", 0, fmt.Errorf("unexpected status: %v", resp.Status)
+		}
+		// The part file already holds the whole file
+		return finishDownload(partPath, localPath)
+	default:
+		return "", 0, fmt.Errorf("unexpected status: %v", resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if maxSize > 0 {
+		if resp.ContentLength > 0 && offset+resp.ContentLength > maxSize {
+			os.Remove(partPath)
+			return "", 0, errQuotaExceeded
+		}
+		// One byte past the limit is enough to know it's too big
+		body = io.LimitReader(resp.Body, maxSize-offset+1)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return "", 0, err
+	}
+	written, err := io.Copy(file, body)
+	closeErr := file.Close()
+	if err != nil {
+		return "", 0, err
+	}
+	if closeErr != nil {
+		return "", 0, closeErr
+	}
+	if maxSize > 0 && offset+written > maxSize {
+		os.Remove(partPath)
+		return "", 0, errQuotaExceeded
+	}
+	return finishDownload(partPath, localPath)
+}
+
+func finishDownload(partPath string, localPath string) (string, int64, error) {
+	err := os.Rename(partPath, localPath)
+	if err != nil {
+		return "", 0, err
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return "", 0, err
+	}
+	return localPath, info.Size(), nil
+}
+
+// Files are stored per feed and named by date and title, for example
+// <dir>/my-podcast/2024-01-31-episode-title.mp3. The folder comes from the
+// feed's name, which unlike its title doesn't change or differ per user
+func enclosurePath(dir string, enclosure database.GetEnclosuresForDownloadRow) string {
+	date := enclosure.CreatedAt
+	if enclosure.PublishedAt.Valid {
+		date = enclosure.PublishedAt.Time
+	}
+	name := date.Format("2006-01-02") + "-" + slugify(enclosure.Title)
+	ext := enclosureExtension(enclosure)
+
+	localPath := filepath.Join(dir, slugify(enclosure.FeedDir), name+ext)
+	// Posts with more than one file of the same type need different names
+	_, err := os.Stat(localPath)
+	if err == nil {
+		short := strings.ReplaceAll(enclosure.ID.String(), "-", "")[:8]
+		localPath = filepath.Join(dir, slugify(enclosure.FeedDir), name+"-"+short+ext)
+	}
+	return localPath
+}
+
+func enclosureExtension(enclosure database.GetEnclosuresForDownloadRow) string {
+	parsed, err := url.Parse(enclosure.Url)
+	if err == nil {
+		ext := path.Ext(parsed.Path)
+		if len(ext) > 1 && len(ext) <= 6 {
+			return strings.ToLower(ext)
+		}
+	}
+	if enclosure.MimeType.Valid {
+		exts, err := mime.ExtensionsByType(enclosure.MimeType.String)
+		if err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ".bin"
+}
+
+// Lower case letters and digits separated by single dashes
+func slugify(value string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > 80 {
+		slug = strings.TrimSuffix(slug[:80], "-")
+	}
+	if slug == "" {
+		slug = "untitled"
+	}
+	return slug
+}
+
+// Downloads media from the feeds the user follows
+func handlerDownload(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	downloadCmd := flag.NewFlagSet("download", flag.ExitOnError)
+	feedURL := downloadCmd.String("feed", "", "Only download media from this feed url")
+	limit := downloadCmd.Int("limit", 0, "Maximum number of files to download")
+	downloadCmd.Parse(cmd.args)
+
+	filter := database.GetEnclosuresForDownloadParams{
+		UserID: uuid.NullUUID{UUID: user.ID, Valid: true},
+	}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedUrl(ctx, *feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Println("URL not found")
+			return err
+		} else if err != nil {
+			fmt.Println("Error getting Feed Details")
+			return err
+		}
+		filter.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	return downloadEnclosures(ctx, s, filter, *limit)
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+	"gator/internal/htmltext"
+
+	"github.com/google/uuid"
+)
+
+// Posts are read in batches so exports of any size use little memory
+const exportBatchSize = 500
+
+// Each export format writes posts one at a time as they are read
+type postExporter interface {
+	writePost(post database.GetPostsForExportRow) error
+	finish() error
+}
+
+// Writes posts from followed feeds and the reading list, oldest first
+func handlerExportPosts(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	exportCmd := flag.NewFlagSet("export-posts", flag.ExitOnError)
+	format := exportCmd.String("format", "jsonl", "Format to export in, jsonl, csv, markdown or mbox")
+	output := exportCmd.String("o", "", "File to write to instead of stdout")
+	feed := exportCmd.String("feed", "", "Only export posts from this feed, by name or url")
+	since := exportCmd.String("since", "", "Only export posts published since this date, or a time ago like 2w")
+	until := exportCmd.String("until", "", "Only export posts published before this date, or a time ago like 2w")
+	saved := exportCmd.Bool("saved", false, "Only export saved posts")
+	exportCmd.Parse(cmd.args)
+
+	params := database.GetPostsForExportParams{
+		UserID:    user.ID,
+		SavedOnly: *saved,
+		Feed:      nullString(*feed),
+		Limit:     exportBatchSize,
+	}
+	if *since != "" {
+		sinceTime, err := parseDateArg(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: sinceTime, Valid: true}
+	}
+	if *until != "" {
+		untilTime, err := parseDateArg(*until)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: untilTime, Valid: true}
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating export file")
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	writer := bufio.NewWriter(out)
+
+	var exporter postExporter
+	switch *format {
+	case "jsonl":
+		exporter = &jsonExporter{encoder: json.NewEncoder(writer)}
+	case "csv":
+		exporter = &csvExporter{writer: csv.NewWriter(writer)}
+	case "markdown":
+		exporter = &markdownExporter{writer: writer}
+	case "mbox":
+		exporter = &mboxExporter{writer: writer}
+	default:
+		return fmt.Errorf("Unsupported export format %v, use jsonl, csv, markdown or mbox", *format)
+	}
+
+	exported := 0
+	for {
+		posts, err := s.db.GetPostsForExport(ctx, params)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error getting posts to export")
+			return err
+		}
+		for _, post := range posts {
+			err = exporter.writePost(post)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error writing post")
+				return err
+			}
+		}
+		exported += len(posts)
+		if len(posts) < exportBatchSize {
+			break
+		}
+
+		last := posts[len(posts)-1]
+		params.AfterDate = sql.NullTime{Time: exportDate(last), Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+
+	err := exporter.finish()
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing export")
+		return err
+	}
+	if *output != "" {
+		fmt.Printf("Exported %v posts to %v\n", exported, *output)
+	}
+	return nil
+}
+
+func exportDate(post database.GetPostsForExportRow) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+// Authors and categories come from the database one per line
+func exportNames(names string) []string {
+	if names == "" {
+		return []string{}
+	}
+	return strings.Split(names, "\n")
+}
+
+type jsonExporter struct {
+	encoder *json.Encoder
+}
+
+type exportedPost struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Feed        string   `json:"feed"`
+	FeedURL     string   `json:"feed_url"`
+	Authors     []string `json:"authors"`
+	Categories  []string `json:"categories"`
+	Published   string   `json:"published"`
+	Saved       bool     `json:"saved"`
+	Note        string   `json:"note,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Content     string   `json:"content,omitempty"`
+}
+
+func (e *jsonExporter) writePost(post database.GetPostsForExportRow) error {
+	return e.encoder.Encode(exportedPost{
+		ID:          post.ID.String(),
+		Title:       post.Title,
+		URL:         post.Url,
+		Feed:        post.FeedName,
+		FeedURL:     post.FeedUrl,
+		Authors:     exportNames(post.Authors),
+		Categories:  exportNames(post.Categories),
+		Published:   exportDate(post).Format(time.RFC3339),
+		Saved:       post.Saved,
+		Note:        post.Note.String,
+		Description: post.Description.String,
+		Content:     post.Content.String,
+	})
+}
+
+func (e *jsonExporter) finish() error {
+	return nil
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+	header bool
+}
+
+func (e *csvExporter) writePost(post database.GetPostsForExportRow) error {
+	if !e.header {
+		e.header = true
+		err := e.writer.Write([]string{"id", "title", "url", "feed", "feed_url", "authors", "categories", "published", "saved", "note", "description", "content"})
+		if err != nil {
+			return err
+		}
+	}
+	return e.writer.Write([]string{
+		post.ID.String(),
+		post.Title,
+		post.Url,
+		post.FeedName,
+		post.FeedUrl,
+		strings.Join(exportNames(post.Authors), ", "),
+		strings.Join(exportNames(post.Categories), ", "),
+		exportDate(post).Format(time.RFC3339),
+		fmt.Sprint(post.Saved),
+		post.Note.String,
+		post.Description.String,
+		post.Content.String,
+	})
+}
+
+func (e *csvExporter) finish() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// A digest with each post's body as plain text
+type markdownExporter struct {
+	writer *bufio.Writer
+	header bool
+}
+
+func (e *markdownExporter) writePost(post database.GetPostsForExportRow) error {
+	if !e.header {
+		e.header = true
+		fmt.Fprintf(e.writer, "# Posts exported from gator\n\n")
+	}
+
+	title := strings.NewReplacer("[", "\\[", "]", "\\]").Replace(headerValue(post.Title))
+	fmt.Fprintf(e.writer, "## [%v](%v)\n\n", title, post.Url)
+	details := []string{post.FeedName, exportDate(post).Format("2006-01-02")}
+	if authors := exportNames(post.Authors); len(authors) > 0 {
+		details = append(details, "by "+strings.Join(authors, ", "))
+	}
+	fmt.Fprintf(e.writer, "*%v*\n\n", strings.Join(details, " · "))
+	if post.Note.Valid {
+		fmt.Fprintf(e.writer, "> %v\n\n", strings.Join(strings.Fields(post.Note.String), " "))
+	}
+	body := htmltext.Render(postBody(post.Content, post.Description), 80)
+	if body != "" {
+		fmt.Fprintf(e.writer, "%v\n\n", body)
+	}
+	_, err := fmt.Fprintf(e.writer, "---\n\n")
+	return err
+}
+
+func (e *markdownExporter) finish() error {
+	return nil
+}
+
+// One message per post in mboxrd format, so any mail client can open it
+type mboxExporter struct {
+	writer *bufio.Writer
+}
+
+func (e *mboxExporter) writePost(post database.GetPostsForExportRow) error {
+	date := exportDate(post)
+	from := mail.Address{Name: post.FeedName, Address: "gator@localhost"}
+
+	fmt.Fprintf(e.writer, "From gator@localhost %v\n", date.UTC().Format(time.ANSIC))
+	fmt.Fprintf(e.writer, "From: %v\n", from.String())
+	fmt.Fprintf(e.writer, "Subject: %v\n", mime.QEncoding.Encode("utf-8", headerValue(post.Title)))
+	fmt.Fprintf(e.writer, "Date: %v\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(e.writer, "Message-ID: <%v@gator>\n", post.ID)
+	fmt.Fprintf(e.writer, "X-Gator-Url: %v\n", headerValue(post.Url))
+	fmt.Fprintf(e.writer, "X-Gator-Feed: %v\n", headerValue(post.FeedUrl))
+	if authors := exportNames(post.Authors); len(authors) > 0 {
+		fmt.Fprintf(e.writer, "X-Gator-Author: %v\n", mime.QEncoding.Encode("utf-8", strings.Join(authors, ", ")))
+	}
+	fmt.Fprintf(e.writer, "MIME-Version: 1.0\n")
+	fmt.Fprintf(e.writer, "Content-Type: text/html; charset=utf-8\n")
+	fmt.Fprintf(e.writer, "Content-Transfer-Encoding: 8bit\n\n")
+
+	body := fmt.Sprintf("<p><a href=\"%v\">%v</a></p>\n%v", post.Url, post.Url, postBody(post.Content, post.Description))
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		// Lines that look like the start of a message get another >
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = ">" + line
+		}
+		fmt.Fprintf(e.writer, "%v\n", line)
+	}
+	_, err := fmt.Fprintf(e.writer, "\n")
+	return err
+}
+
+func (e *mboxExporter) finish() error {
+	return nil
+}
+
+// Header values have to stay on one line
+func headerValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
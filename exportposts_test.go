@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// Exports are piped to a file, so stdout has to be the posts and nothing else
+func TestExportPostsStdout(t *testing.T) {
+	s, mock := newCommandTest(t)
+	mock.ExpectQuery("GetPostsForExport").WillReturnRows(sqlmock.NewRows([]string{
+		"id", "title", "url", "description", "content", "published_at", "created_at",
+		"feed_name", "feed_url", "authors", "categories", "saved", "note",
+	}).AddRow(
+		readerPostID[0].String(), "Post 1", "https://blog.example.com/1", "Summary", nil, readerCreated, readerCreated,
+		"Example Blog", readerFeedURL, "Ann Author", "Tech", true, "Worth a read",
+	).AddRow(
+		readerPostID[1].String(), "Post 2", "https://blog.example.com/2", nil, nil, nil, readerCreated,
+		"Example Blog", readerFeedURL, "", "", false, nil,
+	))
+
+	out := runStdout(t, s, "export-posts", handlerExportPosts, "--format", "jsonl")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("stdout = %q, want a line for each post", out)
+	}
+	for i, line := range lines {
+		var post exportedPost
+		err := json.Unmarshal([]byte(line), &post)
+		if err != nil {
+			t.Fatalf("line %v isn't a post: %v\n%v", i+1, err, line)
+		}
+		if post.ID != readerPostID[i].String() {
+			t.Errorf("line %v id = %v, want %v", i+1, post.ID, readerPostID[i])
+		}
+	}
+}
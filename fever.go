@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+)
+
+// The Fever API, which some simpler clients only support. Clients send an
+// api_key that's the MD5 of "name:password", so it's only stored for users
+// who turn it on with passwd --fever on
+const (
+	feverAPIVersion = 3
+	// Fever returns at most 50 items at a time
+	feverMaxItems = 50
+)
+
+func (api *apiServer) feverRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/fever/", api.fever)
+}
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// Every request goes to /fever/?api, with what's wanted as more parameters
+// such as &feeds or &items&since_id=10
+func (api *apiServer) fever(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	if !query.Has("api") {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+
+	result := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+	key := strings.ToLower(r.Form.Get("api_key"))
+	if key == "" {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+	user, err := api.s.db.GetUserByFeverKey(ctx, sql.NullString{String: key, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusOK, result)
+		return
+	} else if err != nil {
+		serverError(w, err)
+		return
+	}
+	result["auth"] = 1
+
+	feeds, err := api.s.db.GetFeverFeeds(ctx, user.ID)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	var lastRefreshed time.Time
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(lastRefreshed) {
+			lastRefreshed = feed.LastFetchedAt.Time
+		}
+	}
+	result["last_refreshed_on_time"] = feverTime(lastRefreshed)
+
+	if r.Form.Get("mark") != "" {
+		err = api.feverMark(ctx, r, user, feeds)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if query.Has("groups") {
+		folders, err := api.s.db.GetFoldersForUser(ctx, user.ID)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		groups := []feverGroup{}
+		for _, folder := range folders {
+			groups = append(groups, feverGroup{ID: folder.FeverID, Title: folder.Name})
+		}
+		result["groups"] = groups
+	}
+	if query.Has("feeds") {
+		feverFeeds := []feverFeed{}
+		for _, feed := range feeds {
+			feverFeeds = append(feverFeeds, feverFeed{
+				ID:                feed.FeverID,
+				Title:             feed.Title,
+				URL:               feed.Url,
+				SiteURL:           feed.SiteLink.String,
+				LastUpdatedOnTime: feverTime(feed.LastFetchedAt.Time),
+			})
+		}
+		result["feeds"] = feverFeeds
+	}
+	if query.Has("groups") || query.Has("feeds") {
+		result["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+	// Favicons and links aren't kept, but clients expect the lists
+	if query.Has("favicons") {
+		result["favicons"] = []any{}
+	}
+	if query.Has("links") {
+		result["links"] = []any{}
+	}
+
+	if query.Has("items") {
+		items, total, err := api.feverItems(ctx, r, user)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		result["items"] = items
+		result["total_items"] = total
+	}
+	if query.Has("unread_item_ids") {
+		ids, err := api.s.db.GetUnreadItemIDs(ctx, user.ID)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		result["unread_item_ids"] = feverIDs(ids)
+	}
+	if query.Has("saved_item_ids") {
+		ids, err := api.s.db.GetSavedItemIDs(ctx, user.ID)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		result["saved_item_ids"] = feverIDs(ids)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Feeds not in a folder aren't in any group
+func feverFeedsGroups(feeds []database.GetFeverFeedsRow) []feverFeedsGroup {
+	groups := []feverFeedsGroup{}
+	feedIDs := make(map[int64][]int64)
+	for _, feed := range feeds {
+		if !feed.GroupID.Valid {
+			continue
+		}
+		if _, ok := feedIDs[feed.GroupID.Int64]; !ok {
+			groups = append(groups, feverFeedsGroup{GroupID: feed.GroupID.Int64})
+		}
+		feedIDs[feed.GroupID.Int64] = append(feedIDs[feed.GroupID.Int64], feed.FeverID)
+	}
+	for i := range groups {
+		groups[i].FeedIDs = feverIDs(feedIDs[groups[i].GroupID])
+	}
+	return groups
+}
+
+// Items are paged by id, upwards from since_id or down from max_id, where a
+// max_id of 0 starts from the newest
+func (api *apiServer) feverItems(ctx context.Context, r *http.Request, user database.User) ([]feverItem, int64, error) {
+	params := database.GetFeverItemsParams{
+		UserID:  user.ID,
+		WithIds: []int64{},
+		Limit:   feverMaxItems,
+	}
+	if withIDs := r.Form.Get("with_ids"); withIDs != "" {
+		for _, value := range strings.Split(withIDs, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("Invalid with_ids %v", withIDs)
+			}
+			params.WithIds = append(params.WithIds, id)
+		}
+	}
+	if sinceID := r.Form.Get("since_id"); sinceID != "" {
+		id, err := strconv.ParseInt(sinceID, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid since_id %v", sinceID)
+		}
+		params.SinceID = sql.NullInt64{Int64: id, Valid: true}
+	}
+	if maxID := r.Form.Get("max_id"); maxID != "" {
+		id, err := strconv.ParseInt(maxID, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid max_id %v", maxID)
+		}
+		params.NewestFirst = true
+		if id > 0 {
+			params.MaxID = sql.NullInt64{Int64: id, Valid: true}
+		}
+	}
+
+	rows, err := api.s.db.GetFeverItems(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := api.s.db.CountFeverItems(ctx, user.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := []feverItem{}
+	for _, row := range rows {
+		item := feverItem{
+			ID:            row.ItemID,
+			FeedID:        row.FeedID,
+			Title:         row.Title,
+			Author:        row.Authors,
+			HTML:          postBody(row.Content, row.Description),
+			URL:           row.Url,
+			CreatedOnTime: row.Published.Unix(),
+		}
+		if row.Read {
+			item.IsRead = 1
+		}
+		if row.Saved {
+			item.IsSaved = 1
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// Marks an item read, unread, saved or unsaved, or a feed or group read.
+// Group 0 is every feed
+func (api *apiServer) feverMark(ctx context.Context, r *http.Request, user database.User, feeds []database.GetFeverFeedsRow) error {
+	mark := r.Form.Get("mark")
+	as := r.Form.Get("as")
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid id %v", r.Form.Get("id"))
+	}
+
+	if mark == "item" {
+		items, err := api.s.db.GetReaderItemsByID(ctx, database.GetReaderItemsByIDParams{
+			UserID:  user.ID,
+			ItemIds: []int64{id},
+		})
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("No item %v", id)
+		}
+		// The same states the Reader API sets
+		switch as {
+		case "read", "unread":
+			return api.readerSetTag(ctx, user, items[0].ID, readerRead, as == "read")
+		case "saved", "unsaved":
+			return api.readerSetTag(ctx, user, items[0].ID, readerStarred, as == "saved")
+		}
+		return fmt.Errorf("Unsupported mark as %v", as)
+	}
+
+	if as != "read" {
+		return fmt.Errorf("Unsupported mark as %v", as)
+	}
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+	}
+	if before := r.Form.Get("before"); before != "" {
+		seconds, err := strconv.ParseInt(before, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid before %v", before)
+		}
+		params.Before = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+	}
+
+	switch mark {
+	case "feed":
+		for _, feed := range feeds {
+			if feed.FeverID == id {
+				params.FeedUrl = sql.NullString{String: feed.Url, Valid: true}
+			}
+		}
+		if !params.FeedUrl.Valid {
+			return fmt.Errorf("No feed %v", id)
+		}
+	case "group":
+		if id != 0 {
+			folders, err := api.s.db.GetFoldersForUser(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			for _, folder := range folders {
+				if folder.FeverID == id {
+					params.Folder = sql.NullString{String: folder.Name, Valid: true}
+				}
+			}
+			if !params.Folder.Valid {
+				return fmt.Errorf("No group %v", id)
+			}
+		}
+	default:
+		return fmt.Errorf("Unsupported mark %v", mark)
+	}
+	_, err = api.s.db.MarkAllPostsRead(ctx, params)
+	return err
+}
+
+func feverIDs(ids []int64) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(values, ",")
+}
+
+func feverTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func feverKey(name string, password string) string {
+	sum := md5.Sum([]byte(name + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// Turns the Fever api_key on or off, it needs the user's password to make
+func setFeverKey(s *state, user database.User, value string) error {
+	ctx := context.Background()
+
+	var key sql.NullString
+	switch value {
+	case "on":
+		if !user.PasswordHash.Valid {
+			return fmt.Errorf("%v needs a password to use the Fever API, set one with passwd", user.Name)
+		}
+		password, err := readPassword("Password: ")
+		if err != nil {
+			fmt.Println("Error reading password")
+			return err
+		}
+		err = checkPassword(user, password)
+		if err != nil {
+			return err
+		}
+		key = sql.NullString{String: feverKey(user.Name, password), Valid: true}
+	case "off":
+	default:
+		return fmt.Errorf("Usage: passwd --fever on|off")
+	}
+
+	err := s.db.SetUserFeverKey(ctx, database.SetUserFeverKeyParams{
+		ID:          user.ID,
+		UpdatedAt:   time.Now(),
+		FeverApiKey: key,
+	})
+	if err != nil {
+		fmt.Println("Error setting Fever API key")
+		return err
+	}
+	if key.Valid {
+		fmt.Printf("Fever API turned on for %v\n", user.Name)
+	} else {
+		fmt.Printf("Fever API turned off for %v\n", user.Name)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Moving a feed to this takes it out of its folder, so no folder can use it
+const noFolder = "none"
+
+func checkFolderName(name string) error {
+	if name == noFolder {
+		return fmt.Errorf("%v is reserved for taking feeds out of their folder, use another name", noFolder)
+	}
+	return nil
+}
+
+// Folders group the feeds a user follows, each follow is in at most one
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No folder command provided, use create, rename, delete, move or list")
+	}
+
+	args := cmd.args[1:]
+	switch cmd.args[0] {
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: folder create [name]")
+		}
+		return createFolder(s, user, args[0])
+	case "rename":
+		if len(args) != 2 {
+			return fmt.Errorf("Usage: folder rename [name] [new name]")
+		}
+		return renameFolder(s, user, args[0], args[1])
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: folder delete [name]")
+		}
+		return deleteFolder(s, user, args[0])
+	case "move":
+		if len(args) != 2 {
+			return fmt.Errorf("Usage: folder move [url] [name]")
+		}
+		return moveToFolder(s, user, args[0], args[1])
+	case "list":
+		return listFolders(s, user)
+	}
+	return fmt.Errorf("Unknown folder command %v, use create, rename, delete, move or list", cmd.args[0])
+}
+
+func createFolder(s *state, user database.User, name string) error {
+	ctx := context.Background()
+
+	err := checkFolderName(name)
+	if err != nil {
+		return err
+	}
+
+	folder, err := s.db.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		fmt.Println("Error creating folder")
+		return err
+	}
+	fmt.Printf("Created folder %v\n", folder.Name)
+	return nil
+}
+
+func renameFolder(s *state, user database.User, name string, newName string) error {
+	ctx := context.Background()
+
+	err := checkFolderName(newName)
+	if err != nil {
+		return err
+	}
+
+	renamed, err := s.db.RenameFolder(ctx, database.RenameFolderParams{
+		UpdatedAt: time.Now(),
+		NewName:   newName,
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		fmt.Println("Error renaming folder")
+		return err
+	}
+	if renamed == 0 {
+		return fmt.Errorf("No folder called %v", name)
+	}
+	fmt.Printf("Renamed folder %v to %v\n", name, newName)
+	return nil
+}
+
+// Feeds in a deleted folder are still followed, just not in a folder
+func deleteFolder(s *state, user database.User, name string) error {
+	ctx := context.Background()
+
+	removed, err := s.db.DeleteFolder(ctx, database.DeleteFolderParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		fmt.Println("Error deleting folder")
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("No folder called %v", name)
+	}
+	fmt.Printf("Deleted folder %v\n", name)
+	return nil
+}
+
+// Moving a feed to noFolder takes it out of its folder
+func moveToFolder(s *state, user database.User, url string, name string) error {
+	ctx := context.Background()
+
+	feed, err := s.db.GetFeedUrl(ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("URL not found")
+		return err
+	} else if err != nil {
+		fmt.Println("Error getting Feed Details")
+		return err
+	}
+
+	var folderID uuid.NullUUID
+	if name != noFolder {
+		folder, err := getFolder(ctx, s, user, name)
+		if err != nil {
+			return err
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	moved, err := s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		UpdatedAt: time.Now(),
+		FolderID:  folderID,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		fmt.Println("Error moving feed")
+		return err
+	}
+	if moved == 0 {
+		return fmt.Errorf("Not following %v", url)
+	}
+	if folderID.Valid {
+		fmt.Printf("Moved %v to %v\n", feed.Name, name)
+	} else {
+		fmt.Printf("Removed %v from its folder\n", feed.Name)
+	}
+	return nil
+}
+
+func listFolders(s *state, user database.User) error {
+	ctx := context.Background()
+
+	folders, err := s.db.GetFoldersForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Println("Error getting folders")
+		return err
+	}
+	if len(folders) == 0 {
+		fmt.Println("No folders found")
+		return nil
+	}
+	for _, folder := range folders {
+		fmt.Printf("%v (%v feeds)\n", folder.Name, folder.FeedCount)
+	}
+	return nil
+}
+
+func getFolder(ctx context.Context, s *state, user database.User, name string) (database.Folder, error) {
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Folder{}, fmt.Errorf("No folder called %v", name)
+	} else if err != nil {
+		fmt.Println("Error getting folder")
+		return database.Folder{}, err
+	}
+	return folder, nil
+}
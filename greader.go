@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// The Google Reader API, which most mobile feed readers can sync with. Only
+// the parts those clients use are here. Clients are pointed at the address
+// serve listens on and login with a user's name and password
+const (
+	readerReadingList = "user/-/state/com.google/reading-list"
+	readerRead        = "user/-/state/com.google/read"
+	readerKeptUnread  = "user/-/state/com.google/kept-unread"
+	readerStarred     = "user/-/state/com.google/starred"
+	readerLabelPrefix = "user/-/label/"
+	// Feeds are named by id, urls would need escaping in stream paths
+	readerFeedPrefix = "feed/"
+	readerItemPrefix = "tag:google.com,2005:reader/item/"
+)
+
+// Clients ask for thousands of item ids at once when syncing
+const readerMaxItems = 10000
+
+// Clients login again with ClientLogin once their session runs out
+const readerSessionLength = 30 * 24 * time.Hour
+
+// Clients POST long lists of item ids here to read them, nothing changes
+const readerItemContentsPath = "/reader/api/0/stream/items/contents"
+
+func (api *apiServer) readerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/accounts/ClientLogin", api.readerLogin)
+	mux.HandleFunc("GET /reader/api/0/token", api.middlewareReader(api.readerToken))
+	mux.HandleFunc("GET /reader/api/0/user-info", api.middlewareReader(api.readerUserInfo))
+	mux.HandleFunc("GET /reader/api/0/subscription/list", api.middlewareReader(api.readerSubscriptions))
+	mux.HandleFunc("GET /reader/api/0/tag/list", api.middlewareReader(api.readerTags))
+	mux.HandleFunc("GET /reader/api/0/unread-count", api.middlewareReader(api.readerUnreadCount))
+	mux.HandleFunc("GET /reader/api/0/stream/items/ids", api.middlewareReader(api.readerItemIDs))
+	mux.HandleFunc(readerItemContentsPath, api.middlewareReader(api.readerItemContents))
+	mux.HandleFunc("GET /reader/api/0/stream/contents/{stream...}", api.middlewareReader(api.readerStreamContents))
+	mux.HandleFunc("POST /reader/api/0/edit-tag", api.middlewareReader(api.readerEditTag))
+	mux.HandleFunc("POST /reader/api/0/mark-all-as-read", api.middlewareReader(api.readerMarkAllRead))
+}
+
+// Like middlewareToken, but for the GoogleLogin tokens ClientLogin hands out
+func (api *apiServer) middlewareReader(handler apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		if !ok || token == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		user, err := api.s.db.GetUserBySession(ctx, hashToken(token))
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			readerError(w, err)
+			return
+		}
+
+		// Changes have to send the token from /token as well
+		err = r.ParseForm()
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		changes := r.Method == http.MethodPost && r.URL.Path != readerItemContentsPath
+		if changes && r.Form.Get("T") != readerEditToken(token) {
+			w.Header().Set("X-Reader-Google-Bad-Token", "true")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// Users without a password can login with a write API token instead
+func (api *apiServer) readerLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "Error=BadAuthentication", http.StatusBadRequest)
+		return
+	}
+	user, err := api.s.db.GetUser(ctx, r.Form.Get("Email"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		readerError(w, err)
+		return
+	}
+
+	err = api.readerCheckLogin(ctx, user, r.Form.Get("Passwd"))
+	if errors.Is(err, errWrongPassword) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		readerError(w, err)
+		return
+	}
+
+	token, err := createSession(ctx, api.s, user, readerSessionLength)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	if r.Form.Get("output") == "json" {
+		writeJSON(w, http.StatusOK, map[string]string{"SID": token, "LSID": token, "Auth": token})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%v\nLSID=%v\nAuth=%v\n", token, token, token)
+}
+
+func (api *apiServer) readerCheckLogin(ctx context.Context, user database.User, password string) error {
+	if user.PasswordHash.Valid {
+		return checkPassword(user, password)
+	}
+	row, err := api.s.db.GetUserByAPIToken(ctx, hashToken(password))
+	if errors.Is(err, sql.ErrNoRows) {
+		return errWrongPassword
+	} else if err != nil {
+		return err
+	}
+	if row.ID != user.ID || row.Scope != scopeWrite {
+		return errWrongPassword
+	}
+	return nil
+}
+
+// Derived from the auth token so it doesn't need storing
+func readerEditToken(token string) string {
+	return hashToken("edit:" + token)[:40]
+}
+
+func (api *apiServer) readerToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, readerEditToken(token))
+}
+
+func (api *apiServer) readerUserInfo(w http.ResponseWriter, r *http.Request, user database.User) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"userId":        user.ID.String(),
+		"userName":      user.Name,
+		"userProfileId": user.ID.String(),
+		"userEmail":     user.Name,
+	})
+}
+
+type readerCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type readerSubscription struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Categories []readerCategory `json:"categories"`
+	URL        string           `json:"url"`
+	HTMLURL    string           `json:"htmlUrl"`
+	IconURL    string           `json:"iconUrl"`
+}
+
+func (api *apiServer) readerSubscriptions(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := api.s.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	subscriptions := []readerSubscription{}
+	for _, follow := range follows {
+		subscription := readerSubscription{
+			ID:         readerFeedPrefix + follow.FeedID.String(),
+			Title:      follow.FeedName,
+			Categories: []readerCategory{},
+			URL:        follow.FeedUrl,
+			HTMLURL:    follow.SiteLink.String,
+		}
+		if follow.FolderName.Valid {
+			subscription.Categories = append(subscription.Categories, readerCategory{
+				ID:    readerLabelPrefix + follow.FolderName.String,
+				Label: follow.FolderName.String,
+			})
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"subscriptions": subscriptions})
+}
+
+// Folders are labels in the Reader API
+func (api *apiServer) readerTags(w http.ResponseWriter, r *http.Request, user database.User) {
+	folders, err := api.s.db.GetFoldersForUser(r.Context(), user.ID)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	tags := []readerCategory{{ID: readerStarred}}
+	for _, folder := range folders {
+		tags = append(tags, readerCategory{ID: readerLabelPrefix + folder.Name, Type: "folder"})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+type readerUnread struct {
+	ID    string `json:"id"`
+	Count int64  `json:"count"`
+}
+
+func (api *apiServer) readerUnreadCount(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := api.s.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	counts := []readerUnread{}
+	var folders []readerUnread
+	var total int64
+	for _, follow := range follows {
+		counts = append(counts, readerUnread{ID: readerFeedPrefix + follow.FeedID.String(), Count: follow.UnreadCount})
+		total += follow.UnreadCount
+
+		// Follows come sorted by folder
+		if !follow.FolderName.Valid {
+			continue
+		}
+		id := readerLabelPrefix + follow.FolderName.String
+		if len(folders) == 0 || folders[len(folders)-1].ID != id {
+			folders = append(folders, readerUnread{ID: id})
+		}
+		folders[len(folders)-1].Count += follow.UnreadCount
+	}
+	counts = append(counts, folders...)
+	counts = append(counts, readerUnread{ID: readerReadingList, Count: total})
+	writeJSON(w, http.StatusOK, map[string]any{"max": total, "unreadcounts": counts})
+}
+
+// Client ids can have the user's id in place of the -
+func readerStreamID(id string) string {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) == 3 && parts[0] == "user" {
+		return "user/-/" + parts[2]
+	}
+	return id
+}
+
+// Builds the query for a stream and the s, xt, it, n, r, ot, nt and c
+// parameters clients page through it with
+func readerQuery(r *http.Request, user database.User, stream string) (database.GetReaderItemsParams, error) {
+	params := database.GetReaderItemsParams{
+		UserID: user.ID,
+		Limit:  20,
+	}
+
+	err := readerFilter(&params, readerStreamID(stream), true)
+	if err != nil {
+		return params, err
+	}
+	for _, exclude := range r.Form["xt"] {
+		err = readerFilter(&params, readerStreamID(exclude), false)
+		if err != nil {
+			return params, err
+		}
+	}
+	for _, include := range r.Form["it"] {
+		err = readerFilter(&params, readerStreamID(include), true)
+		if err != nil {
+			return params, err
+		}
+	}
+
+	if n := r.Form.Get("n"); n != "" {
+		limit, err := strconv.Atoi(n)
+		if err != nil || limit < 1 {
+			return params, fmt.Errorf("Invalid n %v", n)
+		}
+		params.Limit = int32(min(limit, readerMaxItems))
+	}
+	params.OldestFirst = r.Form.Get("r") == "o"
+	for _, param := range []struct {
+		name  string
+		value *sql.NullTime
+	}{{"ot", &params.Since}, {"nt", &params.Until}} {
+		if value := r.Form.Get(param.name); value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return params, fmt.Errorf("Invalid %v %v", param.name, value)
+			}
+			*param.value = sql.NullTime{Time: time.Unix(seconds, 0), Valid: true}
+		}
+	}
+	if c := r.Form.Get("c"); c != "" {
+		after, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("Invalid continuation %v", c)
+		}
+		params.AfterItem = sql.NullInt64{Int64: after, Valid: true}
+	}
+	return params, nil
+}
+
+// Narrows the query to a stream, or away from it when include is false.
+// Only the read state can be excluded
+func readerFilter(params *database.GetReaderItemsParams, stream string, include bool) error {
+	switch {
+	case stream == readerRead:
+		params.Read = sql.NullBool{Bool: include, Valid: true}
+		return nil
+	case !include:
+		return fmt.Errorf("Unsupported stream to exclude %v", stream)
+	case stream == "" || stream == readerReadingList:
+		return nil
+	case stream == readerStarred:
+		params.StarredOnly = true
+		return nil
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		params.Folder = sql.NullString{String: strings.TrimPrefix(stream, readerLabelPrefix), Valid: true}
+		return nil
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		feedID, err := uuid.Parse(strings.TrimPrefix(stream, readerFeedPrefix))
+		if err != nil {
+			return fmt.Errorf("Unknown feed %v", stream)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+		return nil
+	}
+	return fmt.Errorf("Unsupported stream %v", stream)
+}
+
+// The next page starts after the last item, when the page was full
+func readerContinuation(items []database.GetReaderItemsRow, limit int32) string {
+	if len(items) == 0 || len(items) < int(limit) {
+		return ""
+	}
+	return strconv.FormatInt(items[len(items)-1].ItemID, 10)
+}
+
+type readerItemRef struct {
+	ID              string   `json:"id"`
+	DirectStreamIDs []string `json:"directStreamIds"`
+	TimestampUsec   string   `json:"timestampUsec"`
+}
+
+func (api *apiServer) readerItemIDs(w http.ResponseWriter, r *http.Request, user database.User) {
+	params, err := readerQuery(r, user, r.Form.Get("s"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := api.s.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+
+	refs := []readerItemRef{}
+	for _, item := range items {
+		refs = append(refs, readerItemRef{
+			ID:              strconv.FormatInt(item.ItemID, 10),
+			DirectStreamIDs: []string{},
+			TimestampUsec:   strconv.FormatInt(item.CreatedAt.UnixMicro(), 10),
+		})
+	}
+	result := map[string]any{"itemRefs": refs}
+	if continuation := readerContinuation(items, params.Limit); continuation != "" {
+		result["continuation"] = continuation
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+type readerLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type readerContent struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type readerOrigin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type readerItem struct {
+	ID            string        `json:"id"`
+	CrawlTimeMsec string        `json:"crawlTimeMsec"`
+	TimestampUsec string        `json:"timestampUsec"`
+	Published     int64         `json:"published"`
+	Updated       int64         `json:"updated"`
+	Title         string        `json:"title"`
+	Canonical     []readerLink  `json:"canonical"`
+	Alternate     []readerLink  `json:"alternate"`
+	Summary       readerContent `json:"summary"`
+	Author        string        `json:"author,omitempty"`
+	Categories    []string      `json:"categories"`
+	Origin        readerOrigin  `json:"origin"`
+}
+
+func newReaderItem(item database.GetReaderItemsRow) readerItem {
+	published := item.CreatedAt
+	if item.PublishedAt.Valid {
+		published = item.PublishedAt.Time
+	}
+
+	categories := []string{readerReadingList}
+	if item.Read {
+		categories = append(categories, readerRead)
+	}
+	if item.Starred {
+		categories = append(categories, readerStarred)
+	}
+	if item.FolderName.Valid {
+		categories = append(categories, readerLabelPrefix+item.FolderName.String)
+	}
+
+	return readerItem{
+		ID:            fmt.Sprintf("%v%016x", readerItemPrefix, item.ItemID),
+		CrawlTimeMsec: strconv.FormatInt(item.CreatedAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(item.CreatedAt.UnixMicro(), 10),
+		Published:     published.Unix(),
+		Updated:       published.Unix(),
+		Title:         item.Title,
+		Canonical:     []readerLink{{Href: item.Url}},
+		Alternate:     []readerLink{{Href: item.Url, Type: "text/html"}},
+		Summary:       readerContent{Direction: "ltr", Content: postBody(item.Content, item.Description)},
+		Author:        item.Authors,
+		Categories:    categories,
+		Origin: readerOrigin{
+			StreamID: readerFeedPrefix + item.FeedID.String(),
+			Title:    item.FeedName,
+			HTMLURL:  item.SiteLink.String,
+		},
+	}
+}
+
+func writeReaderItems(w http.ResponseWriter, stream string, items []database.GetReaderItemsRow, continuation string) {
+	result := map[string]any{
+		"direction": "ltr",
+		"id":        stream,
+		"updated":   time.Now().Unix(),
+	}
+	readerItems := []readerItem{}
+	for _, item := range items {
+		readerItems = append(readerItems, newReaderItem(item))
+	}
+	result["items"] = readerItems
+	if continuation != "" {
+		result["continuation"] = continuation
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Item ids come in either the long hex form or as plain numbers
+func parseReaderItemID(id string) (int64, error) {
+	if hexID, ok := strings.CutPrefix(id, readerItemPrefix); ok {
+		itemID, err := strconv.ParseUint(hexID, 16, 64)
+		return int64(itemID), err
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// Looks up the items in the request's i parameters
+func (api *apiServer) readerItems(r *http.Request, user database.User) ([]database.GetReaderItemsRow, error) {
+	var ids []int64
+	for _, value := range r.Form["i"] {
+		id, err := parseReaderItemID(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid item id %v", value)
+		}
+		ids = append(ids, id)
+	}
+
+	rows, err := api.s.db.GetReaderItemsByID(r.Context(), database.GetReaderItemsByIDParams{
+		UserID:  user.ID,
+		ItemIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]database.GetReaderItemsRow, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, database.GetReaderItemsRow(row))
+	}
+	return items, nil
+}
+
+func (api *apiServer) readerItemContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	items, err := api.readerItems(r, user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeReaderItems(w, readerReadingList, items, "")
+}
+
+// The stream can be in the path or in s
+func (api *apiServer) readerStreamContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	stream := r.PathValue("stream")
+	if stream == "" {
+		stream = r.Form.Get("s")
+	}
+	params, err := readerQuery(r, user, stream)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	items, err := api.s.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	writeReaderItems(w, stream, items, readerContinuation(items, params.Limit))
+}
+
+// Adds and removes the read and starred states on items
+func (api *apiServer) readerEditTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+
+	items, err := api.readerItems(r, user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, item := range items {
+		for _, tag := range r.Form["a"] {
+			err = api.readerSetTag(ctx, user, item.ID, readerStreamID(tag), true)
+			if err != nil {
+				readerError(w, err)
+				return
+			}
+		}
+		for _, tag := range r.Form["r"] {
+			err = api.readerSetTag(ctx, user, item.ID, readerStreamID(tag), false)
+			if err != nil {
+				readerError(w, err)
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+// Labels on single items aren't supported, gator files feeds not posts
+func (api *apiServer) readerSetTag(ctx context.Context, user database.User, postID uuid.UUID, tag string, add bool) error {
+	switch tag {
+	case readerKeptUnread:
+		add = !add
+		fallthrough
+	case readerRead:
+		if add {
+			return api.s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+				UserID: user.ID,
+				PostID: postID,
+				ReadAt: time.Now(),
+			})
+		}
+		_, err := api.s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: postID,
+		})
+		return err
+	case readerStarred:
+		if add {
+			return api.s.db.SavePost(ctx, database.SavePostParams{
+				UserID:    user.ID,
+				PostID:    postID,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		}
+		_, err := api.s.db.UnsavePost(ctx, database.UnsavePostParams{
+			UserID: user.ID,
+			PostID: postID,
+		})
+		return err
+	}
+	return nil
+}
+
+// Marks everything in a feed, folder or the reading list as read, up to ts
+func (api *apiServer) readerMarkAllRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	ctx := r.Context()
+
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now(),
+		UserID: user.ID,
+	}
+	stream := readerStreamID(r.Form.Get("s"))
+	switch {
+	case stream == "" || stream == readerReadingList:
+	case strings.HasPrefix(stream, readerLabelPrefix):
+		params.Folder = sql.NullString{String: strings.TrimPrefix(stream, readerLabelPrefix), Valid: true}
+	case strings.HasPrefix(stream, readerFeedPrefix):
+		feedID, err := uuid.Parse(strings.TrimPrefix(stream, readerFeedPrefix))
+		if err != nil {
+			http.Error(w, "Unknown feed "+stream, http.StatusBadRequest)
+			return
+		}
+		feed, err := api.s.db.GetFeedURLfromID(ctx, feedID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Unknown feed "+stream, http.StatusBadRequest)
+			return
+		} else if err != nil {
+			readerError(w, err)
+			return
+		}
+		params.FeedUrl = sql.NullString{String: feed.Url, Valid: true}
+	default:
+		http.Error(w, "Unsupported stream "+stream, http.StatusBadRequest)
+		return
+	}
+
+	// ts is in microseconds, compared with when posts were collected as
+	// that's the timestampUsec items are sent with, so the item whose
+	// timestamp was sent is marked too
+	if ts := r.Form.Get("ts"); ts != "" {
+		usec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid ts "+ts, http.StatusBadRequest)
+			return
+		}
+		params.CreatedUntil = sql.NullTime{Time: time.UnixMicro(usec), Valid: true}
+	}
+
+	_, err := api.s.db.MarkAllPostsRead(ctx, params)
+	if err != nil {
+		readerError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
+
+// Reader clients expect plain text errors rather than JSON ones
+func readerError(w http.ResponseWriter, err error) {
+	log.Printf("Error handling reader request: %v", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// These replay the requests a Reader client makes while syncing against
+// the mux, with the database mocked out so each request's queries are checked
+
+var (
+	readerUserID = uuid.MustParse("5b1d0c36-2f4e-4d8a-9a51-0e6c1f2b7a10")
+	readerFeedID = uuid.MustParse("9e3a47c2-61b8-4f0d-8c25-7d4e2a9b1f63")
+	readerPostID = []uuid.UUID{
+		uuid.MustParse("0c8f5a1e-3b7d-4e62-a9f4-1d2c6b8e5a01"),
+		uuid.MustParse("0c8f5a1e-3b7d-4e62-a9f4-1d2c6b8e5a02"),
+		uuid.MustParse("0c8f5a1e-3b7d-4e62-a9f4-1d2c6b8e5a03"),
+	}
+	readerCreated = time.Date(2024, 3, 1, 9, 30, 0, 123456000, time.UTC)
+)
+
+const readerFeedURL = "https://blog.example.com/feed.xml"
+
+// Expectations name the sqlc query instead of repeating its SQL
+var queryNamed = sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+	if !strings.HasPrefix(actual, "-- name: "+expected+" ") {
+		name, _, _ := strings.Cut(actual, "\n")
+		return fmt.Errorf("expected query %v, got %v", expected, name)
+	}
+	return nil
+})
+
+// Matches times in any location, the database hands them back in UTC
+type timeArg time.Time
+
+func (a timeArg) Match(value driver.Value) bool {
+	t, ok := value.(time.Time)
+	return ok && t.Equal(time.Time(a))
+}
+
+// Matches a session expiry about readerSessionLength from now
+type expiresArg struct{}
+
+func (expiresArg) Match(value driver.Value) bool {
+	t, ok := value.(time.Time)
+	until := time.Until(t)
+	return ok && until > readerSessionLength-time.Minute && until <= readerSessionLength
+}
+
+func newReaderTest(t *testing.T) (http.Handler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(queryNamed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	api := &apiServer{s: &state{db: database.New(db)}}
+	return api.routes(), mock
+}
+
+func readerRequest(t *testing.T, handler http.Handler, method string, target string, form url.Values, auth string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+	r := httptest.NewRequest(method, target, body)
+	if form != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if auth != "" {
+		r.Header.Set("Authorization", "GoogleLogin auth="+auth)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func decodeReader(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %v, want 200: %v", w.Code, w.Body.String())
+	}
+	err := json.Unmarshal(w.Body.Bytes(), v)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+func readerUserRows(t *testing.T) *sqlmock.Rows {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "password_hash", "fever_api_key"}).
+		AddRow(readerUserID.String(), readerCreated, readerCreated, "alice", string(hash), nil)
+}
+
+func expectReaderSession(t *testing.T, mock sqlmock.Sqlmock, token string) {
+	mock.ExpectQuery("GetUserBySession").WithArgs(hashToken(token)).WillReturnRows(readerUserRows(t))
+}
+
+// Item n is the nth post, created a minute after the one before
+func readerItemRows(items ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "item_id", "title", "url", "description", "content", "published_at", "created_at",
+		"feed_id", "feed_name", "site_link", "folder_name", "authors", "read", "starred",
+	})
+	for _, n := range items {
+		created := readerCreated.Add(time.Duration(n) * time.Minute)
+		rows.AddRow(
+			readerPostID[n-1].String(), int64(n), fmt.Sprintf("Post %d", n), fmt.Sprintf("https://blog.example.com/%d", n),
+			"Summary", fmt.Sprintf("<p>Post %d</p>", n), nil, created,
+			readerFeedID.String(), "Example Blog", "https://blog.example.com", "Tech", "Ann Author", false, n == 3,
+		)
+	}
+	return rows
+}
+
+func TestReaderSync(t *testing.T) {
+	handler, mock := newReaderTest(t)
+
+	// Login
+	mock.ExpectQuery("GetUser").WithArgs("alice").WillReturnRows(readerUserRows(t))
+	mock.ExpectExec("CreateSession").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), readerUserID.String(), sqlmock.AnyArg(), expiresArg{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w := readerRequest(t, handler, "POST", "/accounts/ClientLogin", url.Values{
+		"Email":       {"alice"},
+		"Passwd":      {"hunter2"},
+		"service":     {"reader"},
+		"accountType": {"HOSTED_OR_GOOGLE"},
+	}, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("ClientLogin status = %v: %v", w.Code, w.Body.String())
+	}
+	var auth string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, "Auth="); ok {
+			auth = value
+		}
+	}
+	if !strings.HasPrefix(auth, tokenPrefix) {
+		t.Fatalf("ClientLogin body = %q, want an Auth token", w.Body.String())
+	}
+
+	// Edit token
+	expectReaderSession(t, mock, auth)
+	w = readerRequest(t, handler, "GET", "/reader/api/0/token", nil, auth)
+	editToken := w.Body.String()
+	if w.Code != http.StatusOK || editToken != readerEditToken(auth) {
+		t.Fatalf("token = %v %q, want %q", w.Code, editToken, readerEditToken(auth))
+	}
+
+	// Subscriptions
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetFeedFollowsForUser").WithArgs(readerUserID.String()).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "created_at", "updated_at", "user_id", "feed_id", "folder_id", "title", "user_name",
+		"feed_name", "folder_name", "feed_url", "site_link", "unread_count",
+	}).AddRow(
+		uuid.New().String(), readerCreated, readerCreated, readerUserID.String(), readerFeedID.String(), uuid.New().String(), nil, "alice",
+		"Example Blog", "Tech", readerFeedURL, "https://blog.example.com", int64(3),
+	))
+	w = readerRequest(t, handler, "GET", "/reader/api/0/subscription/list?output=json", nil, auth)
+	var subscriptions struct {
+		Subscriptions []readerSubscription `json:"subscriptions"`
+	}
+	decodeReader(t, w, &subscriptions)
+	want := readerSubscription{
+		ID:         "feed/" + readerFeedID.String(),
+		Title:      "Example Blog",
+		Categories: []readerCategory{{ID: "user/-/label/Tech", Label: "Tech"}},
+		URL:        readerFeedURL,
+		HTMLURL:    "https://blog.example.com",
+	}
+	if len(subscriptions.Subscriptions) != 1 || fmt.Sprint(subscriptions.Subscriptions[0]) != fmt.Sprint(want) {
+		t.Errorf("subscriptions = %+v, want %+v", subscriptions.Subscriptions, want)
+	}
+
+	// Unread item ids, a page at a time
+	var ids struct {
+		ItemRefs     []readerItemRef `json:"itemRefs"`
+		Continuation string          `json:"continuation"`
+	}
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetReaderItems").
+		WithArgs(readerUserID.String(), nil, nil, false, false, nil, nil, nil, false, 2).
+		WillReturnRows(readerItemRows(1, 2))
+	w = readerRequest(t, handler, "GET", "/reader/api/0/stream/items/ids?output=json&s=user/-/state/com.google/reading-list&xt=user/-/state/com.google/read&n=2", nil, auth)
+	decodeReader(t, w, &ids)
+	if len(ids.ItemRefs) != 2 || ids.ItemRefs[0].ID != "1" || ids.ItemRefs[1].ID != "2" || ids.Continuation != "2" {
+		t.Fatalf("first page = %+v", ids)
+	}
+	if ids.ItemRefs[1].TimestampUsec != fmt.Sprint(readerCreated.Add(2*time.Minute).UnixMicro()) {
+		t.Errorf("timestampUsec = %v, want the time item 2 was collected", ids.ItemRefs[1].TimestampUsec)
+	}
+
+	continuation := ids.Continuation
+	ids.Continuation = ""
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetReaderItems").
+		WithArgs(readerUserID.String(), nil, nil, false, false, nil, nil, 2, false, 2).
+		WillReturnRows(readerItemRows(3))
+	w = readerRequest(t, handler, "GET", "/reader/api/0/stream/items/ids?output=json&s=user/-/state/com.google/reading-list&xt=user/-/state/com.google/read&n=2&c="+continuation, nil, auth)
+	decodeReader(t, w, &ids)
+	if len(ids.ItemRefs) != 1 || ids.ItemRefs[0].ID != "3" || ids.Continuation != "" {
+		t.Fatalf("last page = %+v, want item 3 and no continuation", ids)
+	}
+
+	// Contents of those items, ids come in both forms
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetReaderItemsByID").
+		WithArgs(readerUserID.String(), "{1,3}").
+		WillReturnRows(readerItemRows(1, 3))
+	w = readerRequest(t, handler, "POST", "/reader/api/0/stream/items/contents?output=json", url.Values{
+		"i": {"1", "tag:google.com,2005:reader/item/0000000000000003"},
+	}, auth)
+	var contents struct {
+		ID    string       `json:"id"`
+		Items []readerItem `json:"items"`
+	}
+	decodeReader(t, w, &contents)
+	if len(contents.Items) != 2 {
+		t.Fatalf("contents = %+v, want 2 items", contents)
+	}
+	item := contents.Items[1]
+	if item.ID != "tag:google.com,2005:reader/item/0000000000000003" ||
+		item.Origin.StreamID != "feed/"+readerFeedID.String() ||
+		item.Summary.Content != "<p>Post 3</p>" ||
+		strings.Join(item.Categories, " ") != "user/-/state/com.google/reading-list user/-/state/com.google/starred user/-/label/Tech" {
+		t.Errorf("item = %+v", item)
+	}
+
+	// Mark one read and star another, the user id can replace the -
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetReaderItemsByID").WithArgs(readerUserID.String(), "{1}").WillReturnRows(readerItemRows(1))
+	mock.ExpectExec("MarkPostRead").
+		WithArgs(readerUserID.String(), readerPostID[0].String(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w = readerRequest(t, handler, "POST", "/reader/api/0/edit-tag", url.Values{
+		"i": {"tag:google.com,2005:reader/item/0000000000000001"},
+		"a": {"user/-/state/com.google/read"},
+		"T": {editToken},
+	}, auth)
+	if w.Code != http.StatusOK || w.Body.String() != "OK" {
+		t.Fatalf("edit-tag read = %v %q", w.Code, w.Body.String())
+	}
+
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetReaderItemsByID").WithArgs(readerUserID.String(), "{2}").WillReturnRows(readerItemRows(2))
+	mock.ExpectExec("SavePost").
+		WithArgs(readerUserID.String(), readerPostID[1].String(), sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w = readerRequest(t, handler, "POST", "/reader/api/0/edit-tag", url.Values{
+		"i": {"2"},
+		"a": {"user/" + readerUserID.String() + "/state/com.google/starred"},
+		"T": {editToken},
+	}, auth)
+	if w.Code != http.StatusOK || w.Body.String() != "OK" {
+		t.Fatalf("edit-tag starred = %v %q", w.Code, w.Body.String())
+	}
+
+	// Mark the feed read up to the newest item the client has seen, ts is
+	// that item's timestampUsec so it's compared with when posts were collected
+	newest := readerCreated.Add(3 * time.Minute)
+	expectReaderSession(t, mock, auth)
+	mock.ExpectQuery("GetFeedURLfromID").WithArgs(readerFeedID.String()).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "created_at", "updated_at", "name", "url", "user_id", "last_fetched_at", "title", "site_link",
+		"description", "language", "image_url", "generator", "copyright", "last_build_date", "fever_id",
+	}).AddRow(
+		readerFeedID.String(), readerCreated, readerCreated, "Example Blog", readerFeedURL, readerUserID.String(), nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, int64(1),
+	))
+	mock.ExpectExec("MarkAllPostsRead").
+		WithArgs(sqlmock.AnyArg(), readerUserID.String(), readerFeedURL, nil, nil, timeArg(newest)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	w = readerRequest(t, handler, "POST", "/reader/api/0/mark-all-as-read", url.Values{
+		"s":  {"feed/" + readerFeedID.String()},
+		"ts": {fmt.Sprint(newest.UnixMicro())},
+		"T":  {editToken},
+	}, auth)
+	if w.Code != http.StatusOK || w.Body.String() != "OK" {
+		t.Fatalf("mark-all-as-read = %v %q", w.Code, w.Body.String())
+	}
+}
+
+func TestReaderEditToken(t *testing.T) {
+	handler, mock := newReaderTest(t)
+	auth := tokenPrefix + "session"
+
+	// Nothing is changed, so no queries beyond the session
+	for _, target := range []string{"/reader/api/0/edit-tag", "/reader/api/0/mark-all-as-read"} {
+		for _, editToken := range []string{readerEditToken(tokenPrefix + "other"), ""} {
+			form := url.Values{
+				"i": {"1"},
+				"a": {"user/-/state/com.google/read"},
+				"s": {"user/-/state/com.google/reading-list"},
+			}
+			if editToken != "" {
+				form.Set("T", editToken)
+			}
+			expectReaderSession(t, mock, auth)
+			w := readerRequest(t, handler, "POST", target, form, auth)
+			if w.Code != http.StatusUnauthorized || w.Header().Get("X-Reader-Google-Bad-Token") != "true" {
+				t.Errorf("%v with T=%q = %v %v, want 401 with X-Reader-Google-Bad-Token", target, editToken, w.Code, w.Header())
+			}
+		}
+	}
+}
+
+func TestReaderUnauthorized(t *testing.T) {
+	handler, mock := newReaderTest(t)
+
+	mock.ExpectQuery("GetUser").WithArgs("alice").WillReturnRows(readerUserRows(t))
+	w := readerRequest(t, handler, "POST", "/accounts/ClientLogin", url.Values{
+		"Email":  {"alice"},
+		"Passwd": {"wrong"},
+	}, "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Error=BadAuthentication") {
+		t.Errorf("ClientLogin with a wrong password = %v %q", w.Code, w.Body.String())
+	}
+
+	w = readerRequest(t, handler, "GET", "/reader/api/0/subscription/list?output=json", nil, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("subscription/list without auth = %v, want 401", w.Code)
+	}
+}
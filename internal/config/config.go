@@ -13,6 +13,56 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
 	DbURL           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	// Set instead of trusting the name for users with a password
+	SessionToken    string `json:"session_token,omitempty"`
+
+	// Media downloads, the retention is per feed and the quota is in MB
+	// Zero means no limit for both
+	DownloadDir       string `json:"download_dir,omitempty"`
+	DownloadRetention int    `json:"download_retention,omitempty"`
+	DownloadQuotaMB   int64  `json:"download_quota_mb,omitempty"`
+
+	// Query parameters stripped from post links, a trailing * matches a prefix
+	TrackingParams []string `json:"tracking_params,omitempty"`
+}
+
+var defaultTrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"yclid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"mkt_tok",
+	"igshid",
+	"ref_src",
+}
+
+// The configured tracking parameters, or the defaults when none are set
+func (cfg *Config) TrackingParameters() []string {
+	if len(cfg.TrackingParams) > 0 {
+		return cfg.TrackingParams
+	}
+	return defaultTrackingParams
+}
+
+const defaultDownloadDir = "gator-downloads"
+
+// Where downloaded media is stored, defaults to ~/gator-downloads
+func (cfg *Config) DownloadPath() (string, error) {
+	if cfg.DownloadDir != "" {
+		return cfg.DownloadDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Print("Error finding home directory")
+		return "", err
+	}
+	return filepath.Join(home, defaultDownloadDir), nil
 }
 
 
@@ -54,6 +104,7 @@ func Read() (Config, error) {
 
 func (cfg *Config) SetUser(user string) error {
 	cfg.CurrentUserName = user
+	cfg.SessionToken = ""
 	if cfg.CurrentUserName == "" {
 		return fmt.Errorf("Error setting username")
 	}
@@ -65,6 +116,21 @@ func (cfg *Config) SetUser(user string) error {
 	return nil
 }
 
+// Logs in a user that has a password, the token is checked on each command
+func (cfg *Config) SetSession(user string, token string) error {
+	cfg.CurrentUserName = user
+	cfg.SessionToken = token
+	if cfg.CurrentUserName == "" || cfg.SessionToken == "" {
+		return fmt.Errorf("Error setting session")
+	}
+	err := write(cfg)
+	if err != nil {
+		log.Print("Error setting session")
+		return err
+	}
+	return nil
+}
+
 func write(cfg *Config) error {
 	
 	body, err := json.Marshal(*cfg) // I'm not sure if thats what I actually want to marshall??
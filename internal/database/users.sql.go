@@ -23,7 +23,7 @@ VALUES (
     $5,
     $6
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, title, site_link, description, language, image_url, generator, copyright, last_build_date, fever_id
 `
 
 type CreateFeedParams struct {
@@ -53,26 +53,36 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.Copyright,
+		&i.LastBuildDate,
+		&i.FeverID,
 	)
 	return i, err
 }
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, title)
     VALUES (
         $1,
         $2,
         $3,
         $4,
-        $5
+        $5,
+        $6
     )
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, title
 )
 
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
-    feeds.name AS feed_name,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder_id, inserted_feed_follow.title,
+    COALESCE(inserted_feed_follow.title, feeds.title, feeds.name) AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
 INNER JOIN users ON inserted_feed_follow.user_id = users.id
@@ -85,6 +95,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Title     sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -93,6 +104,8 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+	Title     sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -104,6 +117,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Title,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -112,14 +126,16 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.Title,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
-const createPosts = `-- name: CreatePosts :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+const createPosts = `-- name: CreatePosts :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id, episode, season, explicit, image_url)
 VALUES (
     $1,
     $2,
@@ -128,9 +144,29 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
 )
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+ON CONFLICT (url) DO UPDATE
+SET updated_at = CASE
+        WHEN posts.title IS DISTINCT FROM EXCLUDED.title
+            OR posts.description IS DISTINCT FROM EXCLUDED.description
+            OR posts.content IS DISTINCT FROM EXCLUDED.content
+        THEN EXCLUDED.updated_at
+        ELSE posts.updated_at
+    END,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    episode = EXCLUDED.episode,
+    season = EXCLUDED.season,
+    explicit = EXCLUDED.explicit,
+    image_url = EXCLUDED.image_url
+RETURNING id
 `
 
 type CreatePostsParams struct {
@@ -140,40 +176,54 @@ type CreatePostsParams struct {
 	Title       string
 	Url         string
 	Description sql.NullString
+	Content     sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Episode     sql.NullInt32
+	Season      sql.NullInt32
+	Explicit    sql.NullBool
+	ImageUrl    sql.NullString
 }
 
-func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) error {
-	_, err := q.db.ExecContext(ctx, createPosts,
+func (q *Queries) CreatePosts(ctx context.Context, arg CreatePostsParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, createPosts,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Title,
 		arg.Url,
 		arg.Description,
+		arg.Content,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Episode,
+		arg.Season,
+		arg.Explicit,
+		arg.ImageUrl,
 	)
-	return err
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, name
+RETURNING id, created_at, updated_at, name, password_hash, fever_api_key
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -182,6 +232,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.PasswordHash,
 	)
 	var i User
 	err := row.Scan(
@@ -189,6 +240,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.PasswordHash,
+		&i.FeverApiKey,
 	)
 	return i, err
 }
@@ -218,7 +271,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const getAllUsers = `-- name: GetAllUsers :many
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, password_hash, fever_api_key FROM users
 `
 
 func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
@@ -235,6 +288,8 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.PasswordHash,
+			&i.FeverApiKey,
 		); err != nil {
 			return nil, err
 		}
@@ -250,20 +305,37 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, users.name AS user_name, feeds.name AS feed_name FROM feed_follows
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder_id, feed_follows.title, users.name AS user_name, COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name, folders.name AS folder_name,
+    feeds.url AS feed_url, feeds.site_link,
+    (
+        SELECT COUNT(*) FROM posts
+        WHERE posts.feed_id = feed_follows.feed_id AND NOT EXISTS (
+            SELECT 1 FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+        )
+    ) AS unread_count
+FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
 WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS LAST, feed_name
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	UserName  string
-	FeedName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	Title       sql.NullString
+	UserName    string
+	FeedName    string
+	FolderName  sql.NullString
+	FeedUrl     string
+	SiteLink    sql.NullString
+	UnreadCount int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -281,8 +353,14 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
+			&i.Title,
 			&i.UserName,
 			&i.FeedName,
+			&i.FolderName,
+			&i.FeedUrl,
+			&i.SiteLink,
+			&i.UnreadCount,
 		); err != nil {
 			return nil, err
 		}
@@ -297,8 +375,27 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
+const getFeedNameForUser = `-- name: GetFeedNameForUser :one
+SELECT COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name
+FROM feeds
+LEFT JOIN feed_follows ON feed_follows.feed_id = feeds.id AND feed_follows.user_id = $1
+WHERE feeds.id = $2
+`
+
+type GetFeedNameForUserParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) GetFeedNameForUser(ctx context.Context, arg GetFeedNameForUserParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getFeedNameForUser, arg.UserID, arg.FeedID)
+	var feed_name string
+	err := row.Scan(&feed_name)
+	return feed_name, err
+}
+
 const getFeedURLfromID = `-- name: GetFeedURLfromID :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, site_link, description, language, image_url, generator, copyright, last_build_date, fever_id FROM feeds
 WHERE id = $1
 `
 
@@ -313,12 +410,21 @@ func (q *Queries) GetFeedURLfromID(ctx context.Context, id uuid.UUID) (Feed, err
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.Copyright,
+		&i.LastBuildDate,
+		&i.FeverID,
 	)
 	return i, err
 }
 
 const getFeedUrl = `-- name: GetFeedUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds 
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, site_link, description, language, image_url, generator, copyright, last_build_date, fever_id FROM feeds 
 WHERE url = $1
 `
 
@@ -333,6 +439,15 @@ func (q *Queries) GetFeedUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.Copyright,
+		&i.LastBuildDate,
+		&i.FeverID,
 	)
 	return i, err
 }
@@ -350,7 +465,7 @@ func (q *Queries) GetFeedUser(ctx context.Context, url string) (uuid.UUID, error
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, title, site_link, description, language, image_url, generator, copyright, last_build_date, fever_id FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -370,6 +485,15 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Title,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.Copyright,
+			&i.LastBuildDate,
+			&i.FeverID,
 		); err != nil {
 			return nil, err
 		}
@@ -398,38 +522,113 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (uuid.UUID, error) {
 
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT 
-    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, 
-    feeds.name as feed_name,
-    users.name as user_name
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.episode, posts.season, posts.explicit, posts.image_url, posts.search_vector, posts.item_id, 
+    COALESCE(feed_follows.title, feeds.title, feeds.name) as feed_name,
+    users.name as user_name,
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ) AS saved
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 INNER JOIN users ON feed_follows.user_id = users.id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.updated_at DESC 
-LIMIT $2
+    AND ($2::text IS NULL OR feed_follows.folder_id IN (
+        SELECT folders.id FROM folders
+        WHERE folders.user_id = feed_follows.user_id AND folders.name = $2::text
+    ))
+    AND ($3::text IS NULL
+        OR feeds.url = $3::text
+        OR feeds.name ILIKE $3::text
+        OR feed_follows.title ILIKE $3::text)
+    AND ($4::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+            AND authors.name ILIKE '%' || $4::text || '%'
+    ))
+    AND ($5::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_categories
+        INNER JOIN categories ON post_categories.category_id = categories.id
+        WHERE post_categories.post_id = posts.id
+            AND lower(categories.name) = lower($5::text)
+    ))
+    AND ($6::text IS NULL
+        OR posts.title ILIKE '%' || $6::text || '%'
+        OR posts.description ILIKE '%' || $6::text || '%'
+        OR posts.content ILIKE '%' || $6::text || '%')
+    AND ($7::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', $7::text))
+    AND ($8::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= $8::timestamp)
+    AND ($9::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < $9::timestamp)
+    AND ($10::boolean IS NULL OR $10::boolean = EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ))
+    AND ($11::timestamp IS NULL
+        OR (COALESCE(posts.published_at, posts.created_at), posts.id) < ($11::timestamp, $12::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT $13
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID    uuid.UUID
+	Folder    sql.NullString
+	Feed      sql.NullString
+	Author    sql.NullString
+	Category  sql.NullString
+	Keyword   sql.NullString
+	Search    sql.NullString
+	Since     sql.NullTime
+	Until     sql.NullTime
+	Read      sql.NullBool
+	AfterDate sql.NullTime
+	AfterID   uuid.NullUUID
+	Limit     int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
-	UserName    string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Content      sql.NullString
+	Episode      sql.NullInt32
+	Season       sql.NullInt32
+	Explicit     sql.NullBool
+	ImageUrl     sql.NullString
+	SearchVector interface{}
+	ItemID       int64
+	FeedName     string
+	UserName     string
+	Read         bool
+	Saved        bool
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Folder,
+		arg.Feed,
+		arg.Author,
+		arg.Category,
+		arg.Keyword,
+		arg.Search,
+		arg.Since,
+		arg.Until,
+		arg.Read,
+		arg.AfterDate,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -446,8 +645,17 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Content,
+			&i.Episode,
+			&i.Season,
+			&i.Explicit,
+			&i.ImageUrl,
+			&i.SearchVector,
+			&i.ItemID,
 			&i.FeedName,
 			&i.UserName,
+			&i.Read,
+			&i.Saved,
 		); err != nil {
 			return nil, err
 		}
@@ -463,7 +671,7 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name FROM users
+SELECT id, created_at, updated_at, name, password_hash, fever_api_key FROM users
 WHERE name = $1
 `
 
@@ -475,6 +683,8 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.PasswordHash,
+		&i.FeverApiKey,
 	)
 	return i, err
 }
@@ -507,3 +717,89 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.UpdatedAt, arg.LastFetchedAt, arg.ID)
 	return err
 }
+
+const renameFeedFollow = `-- name: RenameFeedFollow :execrows
+UPDATE feed_follows
+SET updated_at = $1, title = $2
+WHERE user_id = $3 AND feed_id = $4
+`
+
+type RenameFeedFollowParams struct {
+	UpdatedAt time.Time
+	Title     sql.NullString
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) RenameFeedFollow(ctx context.Context, arg RenameFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameFeedFollow,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.UserID,
+		arg.FeedID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET updated_at = $2, password_hash = $3
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID           uuid.UUID
+	UpdatedAt    time.Time
+	PasswordHash sql.NullString
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.UpdatedAt, arg.PasswordHash)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET updated_at = $2,
+    title = $3,
+    site_link = $4,
+    description = $5,
+    language = $6,
+    image_url = $7,
+    generator = $8,
+    copyright = $9,
+    last_build_date = $10
+WHERE id = $1
+`
+
+type UpdateFeedMetadataParams struct {
+	ID            uuid.UUID
+	UpdatedAt     time.Time
+	Title         sql.NullString
+	SiteLink      sql.NullString
+	Description   sql.NullString
+	Language      sql.NullString
+	ImageUrl      sql.NullString
+	Generator     sql.NullString
+	Copyright     sql.NullString
+	LastBuildDate sql.NullTime
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.SiteLink,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Generator,
+		arg.Copyright,
+		arg.LastBuildDate,
+	)
+	return err
+}
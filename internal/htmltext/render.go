@@ -0,0 +1,256 @@
+package htmltext
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Render turns a post body into plain text for the terminal, wrapped to
+// width, with links and images listed as numbered footnotes at the end
+func Render(body string, width int) string {
+	if strings.TrimSpace(body) == "" {
+		return ""
+	}
+	nodes, err := parseFragment(body)
+	if err != nil {
+		return wrap(body, width)
+	}
+
+	r := &renderer{width: width}
+	for _, node := range nodes {
+		r.render(node)
+	}
+	r.endBlock()
+
+	text := strings.TrimRight(r.out.String(), "\n")
+	if len(r.links) > 0 {
+		text += "\n\n"
+		for i, link := range r.links {
+			text += fmt.Sprintf("[%d] %s\n", i+1, link)
+		}
+		text = strings.TrimRight(text, "\n")
+	}
+	return text
+}
+
+type renderer struct {
+	width  int
+	out    strings.Builder
+	inline strings.Builder
+	links  []string
+
+	// Prefixes for blockquotes and list items, the marker is only used on
+	// the first line of a list item
+	indent []string
+	marker string
+	lists  []int
+
+	// Blank lines are held back until there is another line to separate
+	blank bool
+}
+
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "li": true,
+	"main": true, "nav": true, "ol": true, "p": true, "section": true,
+	"table": true, "tr": true, "ul": true, "caption": true,
+}
+
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "head": true, "title": true,
+	"noscript": true, "template": true, "svg": true,
+}
+
+func (r *renderer) render(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.inline.WriteString(node.Data)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	tag := node.Data
+	if skippedTags[tag] {
+		return
+	}
+
+	switch tag {
+	case "br":
+		r.flush()
+		return
+	case "hr":
+		r.endBlock()
+		r.writeLine(strings.Repeat("-", min(r.width, 40)))
+		r.blankLine()
+		return
+	case "img":
+		alt := attrValue(node.Attr, "alt")
+		if alt == "" {
+			alt = "image"
+		}
+		r.inline.WriteString(fmt.Sprintf(" [%s]%s ", alt, r.footnote(attrValue(node.Attr, "src"))))
+		return
+	case "pre":
+		r.endBlock()
+		r.renderPre(node)
+		r.blankLine()
+		return
+	case "td", "th":
+		if node.PrevSibling != nil {
+			r.inline.WriteString(" | ")
+		}
+	}
+
+	if blockTags[tag] {
+		r.endBlock()
+	}
+
+	switch tag {
+	case "blockquote":
+		r.indent = append(r.indent, "> ")
+	case "ul":
+		r.lists = append(r.lists, 0)
+	case "ol":
+		r.lists = append(r.lists, 1)
+	case "li":
+		r.marker = "- "
+		if len(r.lists) > 0 && r.lists[len(r.lists)-1] > 0 {
+			r.marker = fmt.Sprintf("%d. ", r.lists[len(r.lists)-1])
+			r.lists[len(r.lists)-1]++
+		}
+		r.indent = append(r.indent, strings.Repeat(" ", len(r.marker)))
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		r.render(child)
+	}
+
+	switch tag {
+	case "a":
+		href := attrValue(node.Attr, "href")
+		if href != "" && !strings.HasPrefix(href, "#") && href != strings.TrimSpace(textOf(node)) {
+			r.inline.WriteString(r.footnote(href))
+		}
+	case "blockquote", "li":
+		r.flush()
+		r.indent = r.indent[:len(r.indent)-1]
+		// An empty list item never wrote its marker
+		r.marker = ""
+	case "ul", "ol":
+		r.lists = r.lists[:len(r.lists)-1]
+	}
+
+	if blockTags[tag] {
+		r.endBlock()
+	}
+}
+
+func (r *renderer) renderPre(node *html.Node) {
+	text := strings.Trim(textOf(node), "\n")
+	for _, line := range strings.Split(text, "\n") {
+		r.writeLine(r.prefix() + "    " + line)
+	}
+}
+
+func (r *renderer) footnote(link string) string {
+	if link == "" {
+		return ""
+	}
+	for i, existing := range r.links {
+		if existing == link {
+			return fmt.Sprintf("[%d]", i+1)
+		}
+	}
+	r.links = append(r.links, link)
+	return fmt.Sprintf("[%d]", len(r.links))
+}
+
+func (r *renderer) prefix() string {
+	return strings.Join(r.indent, "")
+}
+
+// Writes out the pending inline text wrapped to the width
+func (r *renderer) flush() {
+	text := strings.Join(strings.Fields(r.inline.String()), " ")
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+
+	prefix := r.prefix()
+	first := prefix
+	if r.marker != "" && len(prefix) >= len(r.marker) {
+		// The marker takes the place of the list item's own indent
+		first = prefix[:len(prefix)-len(r.marker)] + r.marker
+		r.marker = ""
+	}
+	lines := strings.Split(wrap(text, r.width-utf8.RuneCountInString(prefix)), "\n")
+	for i, line := range lines {
+		if i == 0 {
+			r.writeLine(first + line)
+		} else {
+			r.writeLine(prefix + line)
+		}
+	}
+}
+
+// Ends a paragraph, leaving a blank line before whatever comes next
+func (r *renderer) endBlock() {
+	r.flush()
+	if len(r.lists) == 0 {
+		r.blankLine()
+	}
+}
+
+func (r *renderer) blankLine() {
+	r.blank = r.out.Len() > 0
+}
+
+func (r *renderer) writeLine(line string) {
+	if r.blank {
+		r.out.WriteString(strings.TrimRight(r.prefix(), " ") + "\n")
+		r.blank = false
+	}
+	r.out.WriteString(strings.TrimRight(line, " ") + "\n")
+}
+
+func textOf(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textOf(child))
+	}
+	return b.String()
+}
+
+// Greedy word wrap, words longer than the width get a line to themselves
+func wrap(text string, width int) string {
+	if width < 20 {
+		width = 20
+	}
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line == "" {
+			line = word
+		} else if utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width {
+			line += " " + word
+		} else {
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
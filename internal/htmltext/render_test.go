@@ -0,0 +1,39 @@
+package htmltext
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		width int
+		want  string
+	}{
+		{"empty", "   ", 80, ""},
+		{"paragraphs", "<p>Hello <b>world</b></p><p>Second</p>", 80, "Hello world\n\nSecond"},
+		{"wrapped", "The quick brown fox jumps over the lazy dog and keeps on running far away", 30, "The quick brown fox jumps over\nthe lazy dog and keeps on\nrunning far away"},
+		{"line break", "line one<br>line two", 80, "line one\nline two"},
+		{"unordered list", "<ul><li>One</li><li>Two</li></ul>", 80, "- One\n- Two"},
+		{"ordered list", "<ol><li>One</li><li>Two</li></ol>", 80, "1. One\n2. Two"},
+		{"nested list", "<ul><li>Outer<ul><li>Inner</li></ul></li></ul>", 80, "- Outer\n  - Inner"},
+		{"empty list item", "<ul><li></li></ul><p>Hello</p>", 80, "Hello"},
+		{"empty ordered list item", "<ol><li></li><li>Two</li></ol>", 80, "2. Two"},
+		{"list item outside a list", "<li></li><li>x</li>", 80, "- x"},
+		{"blockquote", "<blockquote><p>Quoted</p><p>Again</p></blockquote>", 80, "> Quoted\n>\n> Again"},
+		{"footnotes", `<p>See <a href="https://example.com/a">this</a> and <img src="https://example.com/i.png" alt="a cat"></p>`, 80, "See this[1] and [a cat][2]\n\n[1] https://example.com/a\n[2] https://example.com/i.png"},
+		{"link that is its own text", `<a href="https://example.com">https://example.com</a>`, 80, "https://example.com"},
+		{"preformatted", "<pre>a  b\n  c</pre>", 80, "    a  b\n      c"},
+		{"rule", "<p>one</p><hr><p>two</p>", 30, "one\n\n------------------------------\n\ntwo"},
+		{"script", "<script>alert(1)</script><p>Text</p>", 80, "Text"},
+		{"table", "<table><tr><td>a</td><td>b</td></tr></table>", 80, "a | b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.body, tt.width)
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package htmltext
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Tags kept in stored post bodies and the attributes allowed on each
+var allowedTags = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"div":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// Tags removed along with everything inside them, any other tag that isn't
+// allowed is unwrapped so its text is kept
+var droppedTags = map[string]bool{
+	"applet":   true,
+	"audio":    true,
+	"button":   true,
+	"embed":    true,
+	"form":     true,
+	"frame":    true,
+	"frameset": true,
+	"head":     true,
+	"iframe":   true,
+	"input":    true,
+	"link":     true,
+	"math":     true,
+	"meta":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+	"video":    true,
+}
+
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// Sanitize strips a post body down to a safe allowlist of tags and
+// attributes, dropping scripts, styles, event handlers and unsafe links
+func Sanitize(body string) string {
+	if strings.TrimSpace(body) == "" {
+		return ""
+	}
+	nodes, err := parseFragment(body)
+	if err != nil {
+		return html.EscapeString(body)
+	}
+
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		for _, clean := range sanitizeNode(node) {
+			err := html.Render(&buf, clean)
+			if err != nil {
+				return html.EscapeString(body)
+			}
+		}
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func parseFragment(body string) ([]*html.Node, error) {
+	context := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	}
+	return html.ParseFragment(strings.NewReader(body), context)
+}
+
+// Returns the nodes that replace node in the sanitized tree
+func sanitizeNode(node *html.Node) []*html.Node {
+	switch node.Type {
+	case html.TextNode:
+		return []*html.Node{node}
+	case html.ElementNode:
+	default:
+		// Comments, doctypes and anything else are dropped
+		return nil
+	}
+
+	tag := strings.ToLower(node.Data)
+	if droppedTags[tag] {
+		return nil
+	}
+
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+		node.RemoveChild(child)
+		children = append(children, sanitizeNode(child)...)
+		child = next
+	}
+
+	allowed, ok := allowedTags[tag]
+	if !ok {
+		return children
+	}
+
+	node.Attr = sanitizeAttrs(node.Attr, allowed)
+	if tag == "img" && attrValue(node.Attr, "src") == "" {
+		return nil
+	}
+	for _, child := range children {
+		node.AppendChild(child)
+	}
+	return []*html.Node{node}
+}
+
+func sanitizeAttrs(attrs []html.Attribute, allowed []string) []html.Attribute {
+	var kept []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !contains(allowed, strings.ToLower(attr.Key)) {
+			continue
+		}
+		attr.Key = strings.ToLower(attr.Key)
+		if urlAttrs[attr.Key] && !safeURL(attr.Val) {
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	return kept
+}
+
+// Only web and mail links, relative links have already been resolved
+func safeURL(value string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func attrValue(attrs []html.Attribute, key string) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
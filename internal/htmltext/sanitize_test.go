@@ -0,0 +1,34 @@
+package htmltext
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty", "  ", ""},
+		{"event handler", `<p onclick="x()">Hi</p>`, "<p>Hi</p>"},
+		{"script", `<script>alert(1)</script>Safe`, "Safe"},
+		{"iframe", `<iframe src="https://e.com"></iframe>after`, "after"},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"mailto link", `<a href="mailto:a@b.c">m</a>`, `<a href="mailto:a@b.c">m</a>`},
+		{"attribute not allowed", `<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com">x</a>`},
+		{"image without a safe source", `<img src="javascript:x">`, ""},
+		{"upper case", `<IMG SRC="https://e.com/a.png" ALT="a">`, `<img src="https://e.com/a.png" alt="a"/>`},
+		{"unknown tag", `<font color="red">kept</font>`, "kept"},
+		{"comment", `<!-- c --><p>p</p>`, "<p>p</p>"},
+		{"entities", `<p>a &amp; b &lt;</p>`, "<p>a &amp; b &lt;</p>"},
+		{"styles and classes", `<div style="x"><span class="c">t</span></div>`, "<div><span>t</span></div>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Sanitize(tt.body)
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Resolves relative links in a fetched feed and canonicalizes post links so
+// the same article always ends up with the same url in the posts table
+func normalizeFeedLinks(feed *RSSFeed, feedURL string, tracking []string) {
+	base, err := url.Parse(feedURL)
+	if err != nil {
+		return
+	}
+
+	// Relative channel links are relative to the feed itself
+	channel := &feed.Channel
+	channel.Link = resolveLink(base, channel.Link)
+	channel.Image.URL = resolveLink(base, channel.Image.URL)
+	if channel.Link != "" {
+		site, err := url.Parse(channel.Link)
+		if err == nil {
+			base = site
+		}
+	}
+
+	for i := range channel.Item {
+		item := &channel.Item[i]
+		item.Link = canonicalURL(resolveLink(base, item.Link), tracking)
+		item.Description = resolveHTMLLinks(base, item.Description)
+		item.Content = resolveHTMLLinks(base, item.Content)
+		item.ItunesImage.Href = resolveLink(base, item.ItunesImage.Href)
+		for j := range item.Enclosures {
+			item.Enclosures[j].URL = canonicalURL(resolveLink(base, item.Enclosures[j].URL), tracking)
+		}
+		for j := range item.Media {
+			item.Media[j].URL = canonicalURL(resolveLink(base, item.Media[j].URL), tracking)
+		}
+	}
+}
+
+func resolveLink(base *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" {
+		return ""
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+var htmlLinkAttr = regexp.MustCompile(`(?i)(\s(?:src|href|poster)\s*=\s*)("[^"]*"|'[^']*')`)
+
+// Rewrites relative src and href attributes, such as images, in post bodies
+func resolveHTMLLinks(base *url.URL, body string) string {
+	if body == "" {
+		return body
+	}
+	return htmlLinkAttr.ReplaceAllStringFunc(body, func(attr string) string {
+		parts := htmlLinkAttr.FindStringSubmatch(attr)
+		quote := parts[2][:1]
+		value := parts[2][1 : len(parts[2])-1]
+		if value == "" || strings.HasPrefix(value, "#") {
+			return attr
+		}
+		return parts[1] + quote + resolveLink(base, value) + quote
+	})
+}
+
+// The canonical form has a lower case scheme and host, no default port,
+// a path of at least "/" and none of the tracking parameters. Fragments are
+// dropped unless they're routes, like #/post/1 or #!post/1, as those are
+// different pages
+func canonicalURL(link string, tracking []string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	// IPv6 addresses need their brackets back
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	parsed.Host = host
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+	if !strings.HasPrefix(parsed.Fragment, "/") && !strings.HasPrefix(parsed.Fragment, "!") {
+		parsed.Fragment = ""
+		parsed.RawFragment = ""
+	}
+	parsed.RawQuery = stripTrackingParams(parsed.RawQuery, tracking)
+	return parsed.String()
+}
+
+// Filters the raw query rather than re-encoding it so the order and
+// encoding of the remaining parameters is left alone
+func stripTrackingParams(rawQuery string, tracking []string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if !isTrackingParam(name, tracking) {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func isTrackingParam(name string, tracking []string) bool {
+	name = strings.ToLower(name)
+	for _, param := range tracking {
+		param = strings.ToLower(param)
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"gator/internal/config"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tracking := (&config.Config{}).TrackingParameters()
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"already canonical", "https://example.com/a?id=1", "https://example.com/a?id=1"},
+		{"upper case scheme and host", "HTTPS://Example.COM/Post", "https://example.com/Post"},
+		{"default http port", "http://example.com:80/a", "http://example.com/a"},
+		{"default https port", "https://example.com:443/a", "https://example.com/a"},
+		{"other port", "https://example.com:8443/a", "https://example.com:8443/a"},
+		{"http port on https", "https://example.com:80/a", "https://example.com:80/a"},
+		{"ipv6 default port", "http://[::1]:80/a", "http://[::1]/a"},
+		{"ipv6 other port", "http://[::1]:8080/a", "http://[::1]:8080/a"},
+		{"empty path", "https://example.com", "https://example.com/"},
+		{"empty path with query", "https://example.com?p=1", "https://example.com/?p=1"},
+		{"anchor", "https://example.com/a#comments", "https://example.com/a"},
+		{"hash route", "https://example.com/#/post/1", "https://example.com/#/post/1"},
+		{"hashbang route", "https://example.com/#!post/1", "https://example.com/#!post/1"},
+		{"tracking params", "https://example.com/a?utm_source=rss&id=2&fbclid=x", "https://example.com/a?id=2"},
+		{"only tracking params", "https://example.com/a?utm_medium=feed&utm_campaign=x", "https://example.com/a"},
+		{"query order kept", "https://example.com/a?b=2&a=1", "https://example.com/a?b=2&a=1"},
+		{"page param kept", "https://example.com/index.php?p=123", "https://example.com/index.php?p=123"},
+		{"trailing slash kept", "https://example.com/a/", "https://example.com/a/"},
+		{"relative", "/a?utm_source=x", "/a?utm_source=x"},
+		{"mailto", "mailto:ann@example.com", "mailto:ann@example.com"},
+		{"unparseable", "http://example.com/%zz", "http://example.com/%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := canonicalURL(tt.link, tracking)
+			if got != tt.want {
+				t.Errorf("canonicalURL(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalURLConfiguredParams(t *testing.T) {
+	tracking := (&config.Config{TrackingParams: []string{"src"}}).TrackingParameters()
+	link := "https://example.com/a?src=rss&utm_source=rss"
+	want := "https://example.com/a?utm_source=rss"
+	got := canonicalURL(link, tracking)
+	if got != want {
+		t.Errorf("canonicalURL(%q) = %q, want %q", link, got, want)
+	}
+}
+
+func TestStripTrackingParams(t *testing.T) {
+	tracking := []string{"ref", "UTM_*"}
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"nothing to strip", "a=1&b=2", "a=1&b=2"},
+		{"exact name", "ref=x&a=1", "a=1"},
+		{"prefix and case", "utm_source=x&Utm_Medium=y&a=1", "a=1"},
+		{"longer name than exact", "referrer=x&a=1", "referrer=x&a=1"},
+		{"escaped name", "%75tm_source=x&a=1", "a=1"},
+		{"no value", "ref&a=1", "a=1"},
+		{"empty parts", "a=1&&b=2&", "a=1&b=2"},
+		{"encoding kept", "q=a%20b+c&ref=1", "q=a%20b+c"},
+		{"repeated params kept", "id=1&id=2", "id=1&id=2"},
+		{"everything stripped", "ref=1&utm_x=2", ""},
+		{"bad escape", "%zz=1&ref=2", "%zz=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stripTrackingParams(tt.query, tracking)
+			if got != tt.want {
+				t.Errorf("stripTrackingParams(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"os"
 	"github.com/google/uuid"
 	"gator/internal/database"
+	"gator/internal/htmltext"
+	"golang.org/x/term"
 	"time"
 	"context"
 	"database/sql"
 	"errors"
-	//"strconv"
+	"strconv"
+	"strings"
 	"flag"
 )
 
@@ -95,12 +98,35 @@ func main() {
 	cmds.register("users", handleGetUsers)
 	cmds.register("agg", handlerAgg)
 	cmds.register("feeds", handlerFeeds)
+	cmds.register("feedinfo", handlerFeedInfo)
 	// Handlers that require login
 	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
 	cmds.register("follow", middlewareLoggedIn(handlerFollow))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
 	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollow))
+	cmds.register("import", middlewareLoggedIn(handlerImport))
+	cmds.register("export", middlewareLoggedIn(handlerExport))
+	cmds.register("export-posts", middlewareLoggedIn(handlerExportPosts))
+	cmds.register("publish", middlewareLoggedIn(handlerPublish))
+	cmds.register("serve", handlerServe)
+	cmds.register("token", middlewareLoggedIn(handlerToken))
+	cmds.register("passwd", middlewareLoggedIn(handlerPasswd))
+	cmds.register("rename-feed", middlewareLoggedIn(handlerRenameFeed))
+	cmds.register("folder", middlewareLoggedIn(handlerFolder))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.register("podcasts", middlewareLoggedIn(handlerPodcasts))
+	cmds.register("download", middlewareLoggedIn(handlerDownload))
+	cmds.register("search", middlewareLoggedIn(handlerSearch))
+	cmds.register("savesearch", middlewareLoggedIn(handlerSaveSearch))
+	cmds.register("searches", middlewareLoggedIn(handlerSearches))
+	cmds.register("deletesearch", middlewareLoggedIn(handlerDeleteSearch))
+	cmds.register("show", middlewareLoggedIn(handlerShow))
+	cmds.register("open", middlewareLoggedIn(handlerOpen))
+	cmds.register("read", middlewareLoggedIn(handlerRead))
+	cmds.register("markallread", middlewareLoggedIn(handlerMarkAllRead))
+	cmds.register("save", middlewareLoggedIn(handlerSave))
+	cmds.register("unsave", middlewareLoggedIn(handlerUnsave))
+	cmds.register("saved", middlewareLoggedIn(handlerSaved))
 
 	// Using command line arguements os.Args
 	
@@ -109,12 +135,12 @@ func main() {
 		return
 	}
 
+	// Diagnostics go to stderr, commands like export write documents to stdout
 	if len(os.Args) < 2 {
-		fmt.Println("Command or username not provided")
+		fmt.Fprintln(os.Stderr, "Command or username not provided")
 		os.Exit(1)
 	}
 	userCommand := os.Args[1]
-	fmt.Printf("User Command: %v\n", userCommand)
 	var commandArgs []string
 	if len(os.Args) > 2 {
 		commandArgs = os.Args[2:]
@@ -127,8 +153,8 @@ func main() {
 		
 	err = cmds.run(&appState, cmd)
 	if err != nil {
-		fmt.Println("Error executing command")
-		fmt.Print(err)
+		fmt.Fprintln(os.Stderr, "Error executing command")
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
@@ -177,6 +203,60 @@ newFollow := database.CreateFeedFollowParams{
 
 */
 
+// Parses flags that come before or after the positional arguments,
+// the flag package stops at the first argument that isn't a flag.
+// Everything after -- is positional, so a search can leave out -words
+func parseFlags(flags *flag.FlagSet, args []string) []string {
+	var positional, rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return append(positional, rest...)
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// The flags that were given, written back out so they can be run again,
+// leaving out the ones named in skip
+func setFlags(flags *flag.FlagSet, skip ...string) string {
+	var args []string
+	flags.Visit(func(f *flag.Flag) {
+		for _, name := range skip {
+			if f.Name == name {
+				return
+			}
+		}
+		value := f.Value.String()
+		if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+			if value == "true" {
+				args = append(args, "--"+f.Name)
+			} else {
+				args = append(args, "--"+f.Name+"="+value)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name, shellQuote(value))
+	})
+	return strings.Join(args, " ")
+}
+
+// Quotes values with spaces or other special characters for the shell
+func shellQuote(value string) string {
+	if value != "" && strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@%+=,") == "" {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
 // Middleware function
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
@@ -186,6 +266,19 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 		ctx := context.Background()
 		user := s.config.CurrentUserName
 
+		// Users with a password are logged in by their session token
+		if s.config.SessionToken != "" {
+			sessionUser, err := s.db.GetUserBySession(ctx, hashToken(s.config.SessionToken))
+			if errors.Is(err, sql.ErrNoRows) {
+				fmt.Println("Session has ended, login again")
+				return err
+			} else if err != nil {
+				fmt.Println("Error checking session")
+				return err
+			}
+			return handler(s, cmd, sessionUser)
+		}
+
 		userName, err := s.db.GetUser(ctx, user)
 		if errors.Is(err, sql.ErrNoRows) {
 			fmt.Println("User doesn't exist")
@@ -194,6 +287,9 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 			fmt.Println("Error checking existing user")
 			return err
 		} 
+		if userName.PasswordHash.Valid {
+			return fmt.Errorf("%v has a password, login to use gator as them", userName.Name)
+		}
 		return handler(s, cmd, userName)
 
 	}
@@ -212,13 +308,23 @@ Commands and usage below
 -------------------------------------------------
 
 Login: enter a user name to login, if not registered will register the user
+Users with a password are asked for it, and stay logged in until they login
+as someone else or change their password.
 
 Usage: login [username]
 
 Register: register a new username if not already created
+A password can be set, leaving it empty lets anyone login as the user.
 
 Usage: register [username]
 
+Passwd: Will set, change or remove the logged in user's password. Changing it
+logs the user out everywhere else. --fever on lets Fever clients login with
+the user's name and password, changing the password turns it off again.
+
+Usage: passwd
+Usage: passwd --fever on|off
+
 Reset: resets the database to an empty state.
 This will clear all saved feeds.
 
@@ -236,11 +342,21 @@ If no time is provided it will default to 60 seconds.
 
 Usage: agg 60s
 
+Usage: agg 60s -download
+This will also download new podcast and media files after each fetch,
+see Download below for where they are saved.
+
 Feeds: Will print the feeds that are saved and the user assoicated
 URL of the feed will also be printed.
 
 Usage: feeds
 
+Feed Info: Will print the channel details saved for a feed,
+such as the site link, language, logo and when it was last built.
+These are refreshed each time the feed is fetched by agg.
+
+Usage: feedinfo [url]
+
 Add Feed: Will add a new feed for the current user. 
 If the URL is not already saved it will add it to feeds.
 
@@ -254,12 +370,195 @@ Unfollow: Will unfollow a feed that the user currently follows.
 
 Usage: unfollow [url]
 
-Browse: Will display the feeds a user currently follows.
+Import: Will follow every feed in an OPML file exported from another reader.
+Feeds gator doesn't know about yet are added, feeds the user already follows
+are skipped, and folders in the file become folders, nested folders are
+named by their path such as News/Tech.
+
+Usage: import [file.opml]
+
+Usage: import [file.opml] --validate
+This will fetch each new feed first and skip any that can't be read.
+
+Export: Will write the feeds the user follows as an OPML file that other
+readers can import, with the user's feed titles, site links and folders.
+
+Usage: export --format opml
+Usage: export --format opml -o subscriptions.opml
+
+Export Posts: Will write out the posts from the feeds the user follows and
+their saved posts, oldest first, as JSON Lines, CSV, a Markdown digest or an
+mbox file that can be opened in a mail client.
+
+Usage: export-posts --format jsonl -o posts.jsonl
+Usage: export-posts --format mbox --feed [name or url] --since 2024-01-01 --until 1w
+Usage: export-posts --format markdown --saved
+This will only export posts on the user's reading list.
+
+Publish: Will write the newest posts from the feeds the user follows as an
+RSS 2.0 or Atom feed that other readers can subscribe to. It can be limited
+to a folder or a saved search, and defaults to 50 posts.
+
+Usage: publish --format rss -o gator.xml --url https://example.com/gator.xml
+Usage: publish --format atom --folder [name] -limit 20
+Usage: publish --search [name]
+
+Serve: Will run a JSON API on /api/v1 for feeds, follows, posts and their
+read and starred state. Posts take the same filters as browse and come a page
+at a time. The API is described at /api/v1/openapi.json, and each user's feed
+is at /api/v1/feed. Requests need an API token from the token command, sent
+as a bearer token. Adding --agg also collects feeds in the same process.
+Mobile readers that sync with the Google Reader API can use the same address,
+logging in with the user's password, or a write token for users without one.
+Fever clients can use /fever/ once it's turned on with passwd --fever on.
+
+Usage: serve
+Usage: serve --addr :8080 --agg 10m
+
+Token: Will manage the API tokens that let other programs use serve as the
+logged in user. Read tokens can only get things, write tokens can also follow
+feeds and mark posts read or starred. A token is only shown when it's created.
+
+Usage: token create [name] --scope read|write
+Usage: token list
+Usage: token revoke [name]
+
+Rename Feed: Will set the title the user sees for a feed they follow, in
+following, browse and exports. Leaving out the title goes back to the
+title the feed gives itself.
+
+Usage: rename-feed [url] [title]
+
+Folder: Will organise the feeds a user follows into folders.
+Feeds can be moved out of a folder by moving them to none, deleting a folder
+keeps following its feeds. Following lists the feeds grouped by folder.
+
+Usage: folder create [name]
+Usage: folder rename [name] [new name]
+Usage: folder delete [name]
+Usage: folder move [url] [name or none]
+Usage: folder list
+
+Browse: Will display the unread posts from the feeds a user currently follows,
+newest first.
 It will default to display 2 records unless specified higher.
 
 Usage: browse -limit 5 
 This will display up to 5 records if avaliable for the current user.
 
+Usage: browse -full
+This will also print the full article of each post, or the summary
+when the feed doesn't include the full article.
+
+Usage: browse --author [name] --category [name]
+This will only display posts by a matching author and/or in a category.
+
+Usage: browse --all
+This will display posts that have already been read as well.
+
+Usage: browse --read
+This will only display posts that have already been read.
+
+Usage: browse --folder [name]
+This will only display posts from the feeds in a folder.
+
+Usage: browse --feed [name or url] --keyword [text]
+This will only display posts from one feed and/or containing a keyword.
+
+Usage: browse --since 2d --until 2024-01-31
+This will only display posts published in a date range. Dates can be a day,
+a full timestamp or a time ago such as 12h, 2d or 1w.
+
+Usage: browse --search [name]
+This will display the posts matching a saved search, across every followed feed.
+
+Usage: browse --after [post]
+Usage: browse --page 3
+Posts are shown newest first by published date. This will display the posts
+that come after a post, or a later page of posts.
+
+Search: Will search the titles and articles of posts from the feeds the user
+follows, best matches first, with the matching words highlighted.
+Use quotes for a phrase, or between words, and a minus sign to leave a word out.
+Words with a minus sign go after --, where they aren't read as flags.
+It will default to display 10 results unless specified.
+
+Usage: search [query] -limit 20 --since 4w
+Usage: search --all -- "generic types" or generics -rust
+This will search posts from every feed, not just the followed ones.
+
+Save Search: Will save a search under a name so it can be browsed like a feed.
+Saving a search with a name that is already used replaces its query.
+
+Usage: savesearch "golang generics" --name go
+Usage: savesearch --name go -- golang -rust
+
+Searches: Will list the user's saved searches with how many unread posts match.
+
+Usage: searches
+
+Delete Search: Will remove a saved search.
+
+Usage: deletesearch [name]
+
+Posts are referred to by the short id shown by browse and saved, the first
+few characters are enough as long as they only match one post. The full id
+or the post's url work too.
+
+Show: Will display a post in full, with its feed, authors, categories,
+published date and link, and mark it as read.
+When run in a terminal the post is shown through the PAGER, or less.
+
+Usage: show [post]
+
+Open: Will open a post in the web browser and mark it as read.
+The browser can be set with the BROWSER environment variable.
+
+Usage: open [post]
+
+Read: Will mark one or more posts as read.
+
+Usage: read [post]
+
+Mark All Read: Will mark every post from the followed feeds as read.
+It can be limited to one feed, a folder or to posts published before a date.
+
+Usage: markallread --feed [url] --before 2024-01-31
+Usage: markallread --folder [name]
+
+Save: Will add a post to the user's reading list, with an optional note.
+Saving a post that is already saved updates its note.
+Media downloaded for saved posts is never removed by the download retention.
+
+Usage: save [post] --note "read this weekend"
+
+Unsave: Will remove a post from the user's reading list.
+
+Usage: unsave [post]
+
+Saved: Will display the user's reading list, newest first.
+It can be filtered by feed name or url, a keyword in the title or note,
+and the date the post was saved.
+
+Usage: saved -limit 20 --feed [name or url] --keyword [text] --since 2024-01-31
+
+Podcasts: Will list the podcast episodes from the feeds the user follows,
+with the season and episode numbers, duration and a link to the media file.
+It will default to display 10 episodes unless specified.
+
+Usage: podcasts -limit 20 -feed [url]
+
+Download: Will download the podcast and media files from the feeds the
+user follows. Files are saved in a folder per feed, named by date and title.
+Interrupted downloads carry on where they left off the next time.
+
+The folder and limits are set in .gatorconfig.json:
+  "download_dir": where to save files, defaults to ~/gator-downloads
+  "download_retention": how many files to keep per feed, older ones are removed
+  "download_quota_mb": the most disk space downloads can use
+
+Usage: download -feed [url] -limit 5
+
 `)
 	return
 }
@@ -293,10 +592,21 @@ func handlerLogin(s *state, cmd command) error {
 		os.Exit(1)
 	}
 
-	err = s.config.SetUser(cmd.args[0])
+	if userReturned.PasswordHash.Valid {
+		password, err := readPassword("Password: ")
+		if err != nil {
+			fmt.Println("Error reading password")
+			return err
+		}
+		err = checkPassword(userReturned, password)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = startSession(ctx, s, userReturned)
 	if err != nil {
 		return fmt.Errorf("Error setting user")
-		os.Exit(1)
 	}
 	fmt.Printf("Current user %v\n", cmd.args[0])
 	return nil
@@ -320,23 +630,28 @@ func handlerRegister(s *state, cmd command) error {
 		os.Exit(1)
 	}
 		
+	// A password is optional, single user setups can leave it empty
+	passwordHash, err := newPassword("Password (leave empty for none): ")
+	if err != nil {
+		return err
+	}
 
 	newArgs := database.CreateUserParams{
 		ID: uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Name: newUser,
+		PasswordHash: passwordHash,
 	}
 
-	_, err = s.db.CreateUser(ctx, newArgs)
+	createdUser, err := s.db.CreateUser(ctx, newArgs)
 	if err != nil {
 		fmt.Println("Unable to create new user")
 		os.Exit(1)
 	}
-	err = s.config.SetUser(newUser)
+	err = startSession(ctx, s, createdUser)
 	if err != nil {
 		return fmt.Errorf("Error setting user")
-		os.Exit(1)
 	}
 	fmt.Printf("New User added, Welcome %v\n", newUser)
 	fmt.Printf("ID: %v\n", newArgs.ID)
@@ -393,8 +708,12 @@ func handlerAgg(s *state, cmd command) error {
 	
 	var timer string
 
-	if len(cmd.args) > 0 {
-		timer = cmd.args[0]
+	aggCmd := flag.NewFlagSet("agg", flag.ExitOnError)
+	download := aggCmd.Bool("download", false, "Download new podcast and media files after each fetch")
+	args := parseFlags(aggCmd, cmd.args)
+
+	if len(args) > 0 {
+		timer = args[0]
 	} else {
 		timer = "60s"
 	}
@@ -416,10 +735,11 @@ func handlerAgg(s *state, cmd command) error {
 	ticker := time.NewTicker(timeBetweenRequests)
 	for ; ; <-ticker.C {
 		_ = scrapeFeeds(s)
+		if *download {
+			_ = downloadEnclosures(context.Background(), s, database.GetEnclosuresForDownloadParams{}, 0)
+		}
 	}
 
-	return nil
-
 
 	/*
 
@@ -491,6 +811,8 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 		UpdatedAt: time.Now(),
 		UserID: user.ID, 
 		FeedID: feed.ID,
+		// The name given to addfeed is the adder's own title for the feed
+		Title: nullString(name),
 	}
 
 	feedFollow, err := s.db.CreateFeedFollow(ctx, newFollow)
@@ -538,6 +860,50 @@ func handlerFeeds(s *state, cmd command) error {
 
 }
 
+func handlerFeedInfo(s *state, cmd command) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No url provided")
+	}
+
+	feed, err := s.db.GetFeedUrl(ctx, cmd.args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("URL not found")
+		return err
+	} else if err != nil {
+		fmt.Println("Error getting Feed Details")
+		return err
+	}
+
+	fmt.Printf("Name: %v\n", feed.Name)
+	fmt.Printf("URL: %v\n", feed.Url)
+	fmt.Printf("Title: %v\n", displayNullString(feed.Title))
+	fmt.Printf("Site: %v\n", displayNullString(feed.SiteLink))
+	fmt.Printf("Description: %v\n", displayNullString(feed.Description))
+	fmt.Printf("Language: %v\n", displayNullString(feed.Language))
+	fmt.Printf("Image: %v\n", displayNullString(feed.ImageUrl))
+	fmt.Printf("Generator: %v\n", displayNullString(feed.Generator))
+	fmt.Printf("Copyright: %v\n", displayNullString(feed.Copyright))
+	fmt.Printf("Last Built: %v\n", displayNullTime(feed.LastBuildDate))
+	fmt.Printf("Last Fetched: %v\n", displayNullTime(feed.LastFetchedAt))
+	return nil
+}
+
+func displayNullString(value sql.NullString) string {
+	if !value.Valid {
+		return "-"
+	}
+	return value.String
+}
+
+func displayNullTime(value sql.NullTime) string {
+	if !value.Valid {
+		return "-"
+	}
+	return value.Time.Format(time.RFC1123)
+}
+
 
 func handlerFollow(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
@@ -612,8 +978,30 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 		}
 	}
 
+	// Follows come back sorted by folder, with the ones not in a folder last
+	grouped := false
+	for _, feed := range follows {
+		grouped = grouped || feed.FolderName.Valid
+	}
+
 	for i, feed := range follows {
-		fmt.Printf("%v. Feeds being followed: %v", (i + 1), feed.FeedName)
+		if grouped && (i == 0 || feed.FolderName != follows[i-1].FolderName) {
+			folder := "No folder"
+			if feed.FolderName.Valid {
+				folder = feed.FolderName.String
+			}
+			unread := int64(0)
+			for _, other := range follows {
+				if other.FolderName == feed.FolderName {
+					unread += other.UnreadCount
+				}
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%v (%v unread)\n", folder, unread)
+		}
+		fmt.Printf("%v. Feeds being followed: %v (%v unread)\n", (i + 1), feed.FeedName, feed.UnreadCount)
 	}
 	return nil
 
@@ -654,6 +1042,67 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
+// Each follower can give a feed their own title, without one the feed's
+// channel title is used
+func handlerRenameFeed(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No url provided")
+	}
+	title := strings.TrimSpace(strings.Join(cmd.args[1:], " "))
+
+	feed, err := s.db.GetFeedUrl(ctx, cmd.args[0])
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("URL not found")
+		return err
+	} else if err != nil {
+		fmt.Println("Error getting Feed Details")
+		return err
+	}
+
+	renamed, err := s.db.RenameFeedFollow(ctx, database.RenameFeedFollowParams{
+		UpdatedAt: time.Now(),
+		Title:     nullString(title),
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil {
+		fmt.Println("Error renaming feed")
+		return err
+	}
+	if renamed == 0 {
+		return fmt.Errorf("Not following %v", cmd.args[0])
+	}
+	if title == "" {
+		fmt.Printf("Removed custom title from %v\n", feed.Url)
+	} else {
+		fmt.Printf("Renamed %v to %v\n", feed.Url, title)
+	}
+	return nil
+}
+
+// Width to wrap text to, $COLUMNS wins over asking the terminal
+func terminalWidth() int {
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err == nil && width > 0 {
+		return width
+	}
+	width, _, err = term.GetSize(int(os.Stdout.Fd()))
+	if err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// The full content when the feed provides it, otherwise the summary
+func postBody(content sql.NullString, description sql.NullString) string {
+	if content.Valid {
+		return content.String
+	}
+	return description.String
+}
+
 func handlerBrowse(s *state, cmd command, user database.User) error {
 	ctx := context.Background()
 	//var browseLimit string
@@ -670,6 +1119,20 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 
 	browseCmd := flag.NewFlagSet("browse", flag.ExitOnError)
 	limit := browseCmd.Int("limit", 2, "Number of posts to show")
+	full := browseCmd.Bool("full", false, "Show the full article body of each post")
+	author := browseCmd.String("author", "", "Only show posts by this author")
+	category := browseCmd.String("category", "", "Only show posts in this category")
+	unread := browseCmd.Bool("unread", true, "Only show posts that haven't been read")
+	all := browseCmd.Bool("all", false, "Show read posts as well as unread ones")
+	read := browseCmd.Bool("read", false, "Only show posts that have been read")
+	folder := browseCmd.String("folder", "", "Only show posts from feeds in this folder")
+	feed := browseCmd.String("feed", "", "Only show posts from this feed, by name or url")
+	keyword := browseCmd.String("keyword", "", "Only show posts with this in the title or body")
+	since := browseCmd.String("since", "", "Only show posts published since this date, or a time ago like 2d")
+	until := browseCmd.String("until", "", "Only show posts published before this date, or a time ago like 1w")
+	search := browseCmd.String("search", "", "Only show posts matching this saved search")
+	after := browseCmd.String("after", "", "Show the posts that come after this post")
+	page := browseCmd.Int("page", 1, "Page of posts to show, each page is -limit posts long")
 
 	browseCmd.Parse(cmd.args)
 
@@ -684,18 +1147,93 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 
 	getPostParams := database.GetPostsForUserParams{
 		UserID: user.ID,
+		Folder: nullString(*folder),
+		Feed: nullString(*feed),
+		Author: nullString(*author),
+		Category: nullString(*category),
+		Keyword: nullString(*keyword),
 		Limit: int32(*limit),
 	}
+	if *read {
+		getPostParams.Read = sql.NullBool{Bool: true, Valid: true}
+	} else if *unread && !*all {
+		getPostParams.Read = sql.NullBool{Bool: false, Valid: true}
+	}
+	if *search != "" {
+		query, err := savedSearchQuery(ctx, s, user, *search)
+		if err != nil {
+			return err
+		}
+		getPostParams.Search = sql.NullString{String: query, Valid: true}
+	}
+	if *since != "" {
+		sinceTime, err := parseDateArg(*since)
+		if err != nil {
+			return err
+		}
+		getPostParams.Since = sql.NullTime{Time: sinceTime, Valid: true}
+	}
+	if *until != "" {
+		untilTime, err := parseDateArg(*until)
+		if err != nil {
+			return err
+		}
+		getPostParams.Until = sql.NullTime{Time: untilTime, Valid: true}
+	}
+	if *after != "" {
+		afterPost, err := resolvePost(ctx, s, user, *after)
+		if err != nil {
+			return err
+		}
+		getPostParams.AfterDate = sql.NullTime{Time: postDate(afterPost), Valid: true}
+		getPostParams.AfterID = uuid.NullUUID{UUID: afterPost.ID, Valid: true}
+	}
 
-	userPosts, err := s.db.GetPostsForUser(ctx, getPostParams)
-	if err != nil {
-		fmt.Println("Error getting user post params")
-		os.Exit(1)
+	// Pages are found by walking from one page to the next rather than
+	// using an offset, so later pages are as quick as the first
+	var userPosts []database.GetPostsForUserRow
+	var err error
+	for i := 0; i < max(*page, 1); i++ {
+		userPosts, err = s.db.GetPostsForUser(ctx, getPostParams)
+		if err != nil {
+			fmt.Println("Error getting user post params")
+			os.Exit(1)
+		}
+		if len(userPosts) == 0 {
+			break
+		}
+		last := userPosts[len(userPosts)-1]
+		getPostParams.AfterDate = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		if last.PublishedAt.Valid {
+			getPostParams.AfterDate.Time = last.PublishedAt.Time
+		}
+		getPostParams.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+
+	if len(userPosts) == 0 && getPostParams.Read.Valid && !getPostParams.Read.Bool {
+		fmt.Println("No unread posts, use browse -all to see read posts too")
 	}
 
 	for _, post := range userPosts {
-		fmt.Printf("Title: %v\n", post.Title)
+		fmt.Printf("ID: %v\n", shortID(post.ID))
+		if post.Read {
+			fmt.Printf("Title: %v (read)\n", post.Title)
+		} else {
+			fmt.Printf("Title: %v\n", post.Title)
+		}
 		fmt.Printf("Url: %v\n", post.Url)
+		if *full {
+			fmt.Printf("%v\n\n", htmltext.Render(postBody(post.Content, post.Description), terminalWidth()))
+		}
+	}
+
+	// The hint keeps the same filters, only the starting post changes
+	if len(userPosts) == *limit {
+		hint := "browse"
+		if flags := setFlags(browseCmd, "after", "page"); flags != "" {
+			hint += " " + flags
+		}
+		fmt.Printf("\nMore posts: %v --after %v\n", hint, shortID(userPosts[len(userPosts)-1].ID))
 	}
 
 	return nil
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestSetFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"none", nil, ""},
+		{"string", []string{"--feed", "Example Blog"}, "--feed 'Example Blog'"},
+		{"quote", []string{"--keyword", "it's"}, `--keyword 'it'\''s'`},
+		{"empty", []string{"--folder="}, "--folder ''"},
+		{"bools", []string{"--all", "--unread=false"}, "--all --unread=false"},
+		{"skipped", []string{"--after", "abc", "--page", "2", "--folder", "News/Tech"}, "--folder News/Tech"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("browse", flag.ContinueOnError)
+			flags.String("feed", "", "")
+			flags.String("folder", "", "")
+			flags.String("keyword", "", "")
+			flags.String("after", "", "")
+			flags.Int("page", 1, "")
+			flags.Bool("all", false, "")
+			flags.Bool("unread", true, "")
+			parseFlags(flags, tt.args)
+
+			got := setFlags(flags, "after", "page")
+			if got != tt.want {
+				t.Errorf("setFlags(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
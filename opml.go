@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// OPML is the subscription list format every feed reader imports and exports
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+type OPMLHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// Outlines with an xmlUrl are feeds, ones without are folders
+type OPMLOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// A feed found in an OPML file and the folder it was in
+type opmlFeed struct {
+	Title  string
+	URL    string
+	Folder string
+}
+
+// Folders are flat, so nested ones are named by their path, such as
+// "News/Tech", and exported back out as nested outlines
+const folderSeparator = "/"
+
+func opmlFeeds(outlines []OPMLOutline, folder string) []opmlFeed {
+	var feeds []opmlFeed
+	for _, outline := range outlines {
+		title := strings.TrimSpace(outline.Title)
+		if title == "" {
+			title = strings.TrimSpace(outline.Text)
+		}
+
+		if url := strings.TrimSpace(outline.XMLURL); url != "" {
+			feeds = append(feeds, opmlFeed{Title: title, URL: url, Folder: folder})
+			continue
+		}
+
+		child := folder
+		if title != "" {
+			child = strings.TrimPrefix(folder+folderSeparator+title, folderSeparator)
+		}
+		feeds = append(feeds, opmlFeeds(outline.Outlines, child)...)
+	}
+	return feeds
+}
+
+// Follows every feed in an OPML file, creating feeds gator doesn't know yet
+func handlerImport(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	validate := importCmd.Bool("validate", false, "Fetch each new feed first and skip the ones that fail")
+	args := parseFlags(importCmd, cmd.args)
+
+	if len(args) == 0 {
+		return fmt.Errorf("No OPML file provided")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Println("Error reading OPML file")
+		return err
+	}
+	var doc OPML
+	err = xml.Unmarshal(data, &doc)
+	if err != nil {
+		fmt.Println("Error parsing OPML file")
+		return err
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Println("Error getting feed follows for user")
+		return err
+	}
+	following := make(map[uuid.UUID]bool)
+	followedURLs := make(map[string]bool)
+	for _, follow := range follows {
+		following[follow.FeedID] = true
+		followedURLs[follow.FeedUrl] = true
+	}
+
+	// Feeds already followed are skipped before validating, so they aren't
+	// fetched for nothing
+	var feeds []opmlFeed
+	seen := make(map[string]bool)
+	total, skipped := 0, 0
+	for _, feed := range opmlFeeds(doc.Body.Outlines, "") {
+		if seen[feed.URL] {
+			continue
+		}
+		seen[feed.URL] = true
+		total++
+		if followedURLs[feed.URL] {
+			skipped++
+			continue
+		}
+		feeds = append(feeds, feed)
+	}
+
+	var invalid map[string]error
+	if *validate {
+		invalid = validateFeeds(ctx, feeds)
+	}
+
+	folders := make(map[string]uuid.UUID)
+	added, failed := 0, 0
+	for _, feed := range feeds {
+		if err, ok := invalid[feed.URL]; ok {
+			fmt.Printf("Failed %v: %v\n", feed.URL, err)
+			failed++
+			continue
+		}
+
+		imported, err := importFeed(ctx, s, user, feed, following, folders)
+		if err != nil {
+			fmt.Printf("Failed %v: %v\n", feed.URL, err)
+			failed++
+		} else if imported {
+			fmt.Printf("Added %v\n", feed.URL)
+			added++
+		} else {
+			skipped++
+		}
+	}
+
+	fmt.Printf("Imported %v feeds: %v added, %v already followed, %v failed\n", total, added, skipped, failed)
+	return nil
+}
+
+// Returns false for feeds the user already follows, those are left where
+// they are rather than moved into the file's folders
+func importFeed(ctx context.Context, s *state, user database.User, feed opmlFeed, following map[uuid.UUID]bool, folders map[string]uuid.UUID) (bool, error) {
+	existing, err := s.db.GetFeedUrl(ctx, feed.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := feed.Title
+		if name == "" {
+			name = feed.URL
+		}
+		existing, err = s.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      name,
+			Url:       feed.URL,
+			UserID:    user.ID,
+		})
+	}
+	if err != nil {
+		return false, err
+	}
+	if following[existing.ID] {
+		return false, nil
+	}
+
+	_, err = s.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		FeedID:    existing.ID,
+		Title:     nullString(feed.Title),
+	})
+	if err != nil {
+		return false, err
+	}
+	following[existing.ID] = true
+
+	if feed.Folder == "" {
+		return true, nil
+	}
+	folderID, ok := folders[feed.Folder]
+	if !ok {
+		folderID, err = importFolder(ctx, s, user, feed.Folder)
+		if err != nil {
+			return false, err
+		}
+		folders[feed.Folder] = folderID
+	}
+	_, err = s.db.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		UpdatedAt: time.Now(),
+		FolderID:  uuid.NullUUID{UUID: folderID, Valid: true},
+		UserID:    user.ID,
+		FeedID:    existing.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Uses the user's folder with that name, creating it if there isn't one
+func importFolder(ctx context.Context, s *state, user database.User, name string) (uuid.UUID, error) {
+	err := checkFolderName(name)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	folder, err := s.db.GetFolderByName(ctx, database.GetFolderByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err == nil {
+		return folder.ID, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return uuid.UUID{}, err
+	}
+
+	folder, err = s.db.CreateFolder(ctx, database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return folder.ID, nil
+}
+
+// Fetches the feeds a few at a time, returning the error for each one that
+// couldn't be fetched or parsed
+func validateFeeds(ctx context.Context, feeds []opmlFeed) map[string]error {
+	const workers = 8
+
+	invalid := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, workers)
+
+	for _, feed := range feeds {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			_, err := fetchFeed(ctx, url)
+			if err != nil {
+				mu.Lock()
+				invalid[url] = err
+				mu.Unlock()
+			}
+		}(feed.URL)
+	}
+	wg.Wait()
+	return invalid
+}
+
+// Writes the user's follows out as OPML 2.0, to stdout unless -o is given
+func handlerExport(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	format := exportCmd.String("format", "opml", "Format to export in, only opml is supported")
+	output := exportCmd.String("o", "", "File to write to instead of stdout")
+	exportCmd.Parse(cmd.args)
+
+	if *format != "opml" {
+		return fmt.Errorf("Unsupported export format %v, use opml", *format)
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error getting feed follows for user")
+		return err
+	}
+
+	doc := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       fmt.Sprintf("%v's subscriptions in gator", user.Name),
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	for _, follow := range follows {
+		outline := OPMLOutline{
+			Text:    follow.FeedName,
+			Title:   follow.FeedName,
+			Type:    "rss",
+			XMLURL:  follow.FeedUrl,
+			HTMLURL: follow.SiteLink.String,
+		}
+		var path []string
+		if follow.FolderName.Valid {
+			path = strings.Split(follow.FolderName.String, folderSeparator)
+		}
+		addOutline(&doc.Body.Outlines, path, outline)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing OPML")
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	err = os.WriteFile(*output, data, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing OPML file")
+		return err
+	}
+	fmt.Printf("Exported %v feeds to %v\n", len(follows), *output)
+	return nil
+}
+
+// Adds a feed under its folder path, creating folder outlines as needed
+func addOutline(outlines *[]OPMLOutline, path []string, outline OPMLOutline) {
+	if len(path) == 0 {
+		*outlines = append(*outlines, outline)
+		return
+	}
+	for i := range *outlines {
+		folder := &(*outlines)[i]
+		if folder.XMLURL == "" && folder.Text == path[0] {
+			addOutline(&folder.Outlines, path[1:], outline)
+			return
+		}
+	}
+	*outlines = append(*outlines, OPMLOutline{Text: path[0], Title: path[0]})
+	addOutline(&(*outlines)[len(*outlines)-1].Outlines, path[1:], outline)
+}
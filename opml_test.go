@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"gator/internal/config"
+	"gator/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// Runs the command as main does and returns what it wrote to stdout
+func runStdout(t *testing.T, s *state, name string, handler func(*state, command, database.User) error, args ...string) string {
+	t.Helper()
+	cmds := commands{cmds: map[string]func(*state, command) error{}}
+	cmds.register(name, middlewareLoggedIn(handler))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	err = cmds.run(s, command{cmd: name, args: args})
+	w.Close()
+	if err != nil {
+		t.Fatalf("%v: %v", name, err)
+	}
+	return <-output
+}
+
+// Commands run as alice, who has no password, so logging in is one query
+func newCommandTest(t *testing.T) (*state, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(queryNamed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		err := mock.ExpectationsWereMet()
+		if err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	mock.ExpectQuery("GetUser").WithArgs("alice").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "password_hash", "fever_api_key"}).
+			AddRow(readerUserID.String(), readerCreated, readerCreated, "alice", nil, nil),
+	)
+	return &state{config: &config.Config{CurrentUserName: "alice"}, db: database.New(db)}, mock
+}
+
+// Export is piped to a file, so stdout has to be the OPML and nothing else
+func TestExportStdout(t *testing.T) {
+	s, mock := newCommandTest(t)
+	mock.ExpectQuery("GetFeedFollowsForUser").WithArgs(readerUserID.String()).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "created_at", "updated_at", "user_id", "feed_id", "folder_id", "title", "user_name",
+		"feed_name", "folder_name", "feed_url", "site_link", "unread_count",
+	}).AddRow(
+		uuid.New().String(), readerCreated, readerCreated, readerUserID.String(), readerFeedID.String(), uuid.New().String(), nil, "alice",
+		"Example Blog", "News/Tech", readerFeedURL, "https://blog.example.com", int64(3),
+	).AddRow(
+		uuid.New().String(), readerCreated, readerCreated, readerUserID.String(), uuid.New().String(), nil, nil, "alice",
+		"Other Blog", nil, "https://other.example.com/rss", nil, int64(0),
+	))
+
+	out := runStdout(t, s, "export", handlerExport)
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Fatalf("stdout = %q, want it to start with the XML header", out)
+	}
+	var doc OPML
+	err := xml.Unmarshal([]byte(out), &doc)
+	if err != nil {
+		t.Fatalf("stdout isn't OPML: %v\n%v", err, out)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := xml.Header + string(data) + "\n"; out != want {
+		t.Errorf("stdout = %q, want only the OPML %q", out, want)
+	}
+
+	feeds := opmlFeeds(doc.Body.Outlines, "")
+	if len(feeds) != 2 || feeds[0] != (opmlFeed{Title: "Example Blog", URL: readerFeedURL, Folder: "News/Tech"}) || feeds[1].URL != "https://other.example.com/rss" {
+		t.Errorf("feeds = %+v", feeds)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+var errWrongPassword = errors.New("Incorrect password")
+
+// Reads a password without echoing it, or a line from stdin when it isn't
+// a terminal so scripts can pipe one in
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Print(prompt)
+		password, err := term.ReadPassword(fd)
+		fmt.Println()
+		return string(password), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Asks for a new password twice and hashes it, leaving it empty means the
+// user won't have a password
+func newPassword(prompt string) (sql.NullString, error) {
+	password, err := readPassword(prompt)
+	if err != nil {
+		fmt.Println("Error reading password")
+		return sql.NullString{}, err
+	}
+	if password == "" {
+		return sql.NullString{}, nil
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		confirm, err := readPassword("Confirm password: ")
+		if err != nil {
+			fmt.Println("Error reading password")
+			return sql.NullString{}, err
+		}
+		if confirm != password {
+			return sql.NullString{}, fmt.Errorf("Passwords don't match")
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Println("Error hashing password")
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(hash), Valid: true}, nil
+}
+
+func checkPassword(user database.User, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return errWrongPassword
+	}
+	return err
+}
+
+// Logs the user in, with a session for users that have a password and
+// just their name for those that don't
+func startSession(ctx context.Context, s *state, user database.User) error {
+	if !user.PasswordHash.Valid {
+		return s.config.SetUser(user.Name)
+	}
+
+	token, err := createSession(ctx, s, user, 0)
+	if err != nil {
+		fmt.Println("Error creating session")
+		return err
+	}
+	return s.config.SetSession(user.Name, token)
+}
+
+// Returns the new session's token, only its hash is stored. Sessions with
+// a zero length never expire
+func createSession(ctx context.Context, s *state, user database.User, length time.Duration) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	var expiresAt sql.NullTime
+	if length > 0 {
+		expiresAt = sql.NullTime{Time: time.Now().Add(length), Valid: true}
+	}
+	err = s.db.CreateSession(ctx, database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Sets, changes or removes the logged in user's password. Other sessions
+// are logged out, and the Fever API is turned off as its key comes from the
+// password
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	passwdCmd := flag.NewFlagSet("passwd", flag.ExitOnError)
+	fever := passwdCmd.String("fever", "", "Turn the Fever API on or off instead of changing the password")
+	args := parseFlags(passwdCmd, cmd.args)
+	if len(args) != 0 {
+		return fmt.Errorf("Usage: passwd --fever on|off")
+	}
+	if *fever != "" {
+		return setFeverKey(s, user, *fever)
+	}
+
+	if user.PasswordHash.Valid {
+		current, err := readPassword("Current password: ")
+		if err != nil {
+			fmt.Println("Error reading password")
+			return err
+		}
+		err = checkPassword(user, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	hash, err := newPassword("New password (leave empty for none): ")
+	if err != nil {
+		return err
+	}
+	err = s.db.SetUserPassword(ctx, database.SetUserPasswordParams{
+		ID:           user.ID,
+		UpdatedAt:    time.Now(),
+		PasswordHash: hash,
+	})
+	if err != nil {
+		fmt.Println("Error setting password")
+		return err
+	}
+	if user.FeverApiKey.Valid {
+		err = s.db.SetUserFeverKey(ctx, database.SetUserFeverKeyParams{
+			ID:        user.ID,
+			UpdatedAt: time.Now(),
+		})
+		if err != nil {
+			fmt.Println("Error turning off Fever API")
+			return err
+		}
+		fmt.Println("Fever API turned off, turn it back on with passwd --fever on")
+	}
+	err = s.db.DeleteSessionsForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Println("Error ending sessions")
+		return err
+	}
+
+	user.PasswordHash = hash
+	err = startSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	if hash.Valid {
+		fmt.Printf("Password set for %v\n", user.Name)
+	} else {
+		fmt.Printf("Password removed for %v\n", user.Name)
+	}
+	return nil
+}
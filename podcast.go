@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// <media:content> from the Media RSS namespace
+type MediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+type ItunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// Left out when gator writes a feed and the post has no image
+func (i ItunesImage) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if i.Href == "" {
+		return nil
+	}
+	type image ItunesImage
+	return e.EncodeElement(image(i), start)
+}
+
+// A media file attached to a post, merged from <enclosure> and <media:content>
+type enclosure struct {
+	URL      string
+	Type     string
+	Length   sql.NullInt64
+	Duration sql.NullInt32
+}
+
+// Collects the audio and video files attached to an item, media:content
+// entries that repeat an enclosure only fill in what the enclosure is missing
+func itemEnclosures(item RSSItem) []enclosure {
+	var enclosures []enclosure
+	seen := make(map[string]int)
+
+	for _, e := range item.Enclosures {
+		if e.URL == "" {
+			continue
+		}
+		if _, ok := seen[e.URL]; ok {
+			continue
+		}
+		seen[e.URL] = len(enclosures)
+		enclosures = append(enclosures, enclosure{
+			URL:    e.URL,
+			Type:   e.Type,
+			Length: parseLength(e.Length),
+		})
+	}
+
+	for _, m := range item.Media {
+		if m.URL == "" {
+			continue
+		}
+		if i, ok := seen[m.URL]; ok {
+			if enclosures[i].Type == "" {
+				enclosures[i].Type = m.Type
+			}
+			if !enclosures[i].Length.Valid {
+				enclosures[i].Length = parseLength(m.FileSize)
+			}
+			if !enclosures[i].Duration.Valid {
+				enclosures[i].Duration = parseItunesDuration(m.Duration)
+			}
+			continue
+		}
+		if !isPlayableMedia(m) {
+			continue
+		}
+		seen[m.URL] = len(enclosures)
+		enclosures = append(enclosures, enclosure{
+			URL:      m.URL,
+			Type:     m.Type,
+			Length:   parseLength(m.FileSize),
+			Duration: parseItunesDuration(m.Duration),
+		})
+	}
+
+	// The episode duration applies to any file that didn't give its own
+	duration := parseItunesDuration(item.ItunesDuration)
+	for i := range enclosures {
+		if !enclosures[i].Duration.Valid {
+			enclosures[i].Duration = duration
+		}
+	}
+	return enclosures
+}
+
+// Media RSS is also used for thumbnails, only audio and video are kept
+func isPlayableMedia(m MediaContent) bool {
+	if m.Medium == "audio" || m.Medium == "video" {
+		return true
+	}
+	return strings.HasPrefix(m.Type, "audio/") || strings.HasPrefix(m.Type, "video/")
+}
+
+func parseLength(value string) sql.NullInt64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length <= 0 {
+		return sql.NullInt64{Valid: false}
+	}
+	return sql.NullInt64{Int64: length, Valid: true}
+}
+
+// itunes:duration is either plain seconds or HH:MM:SS / MM:SS
+func parseItunesDuration(value string) sql.NullInt32 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullInt32{Valid: false}
+	}
+	seconds := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return sql.NullInt32{Valid: false}
+		}
+		seconds = seconds*60 + int(n)
+	}
+	return sql.NullInt32{Int32: int32(seconds), Valid: true}
+}
+
+func parseItunesNumber(value string) sql.NullInt32 {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return sql.NullInt32{Valid: false}
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}
+}
+
+func parseItunesExplicit(value string) sql.NullBool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "true", "explicit":
+		return sql.NullBool{Bool: true, Valid: true}
+	case "no", "false", "clean":
+		return sql.NullBool{Bool: false, Valid: true}
+	}
+	return sql.NullBool{Valid: false}
+}
+
+func savePostEnclosures(ctx context.Context, s *state, postID uuid.UUID, item RSSItem) error {
+	for _, e := range itemEnclosures(item) {
+		err := s.db.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			PostID:    postID,
+			Url:       e.URL,
+			MimeType:  nullString(e.Type),
+			Length:    e.Length,
+			Duration:  e.Duration,
+		})
+		if err != nil {
+			fmt.Println("Error saving post enclosure")
+			return err
+		}
+	}
+	return nil
+}
+
+func formatDuration(seconds int32) string {
+	d := time.Duration(seconds) * time.Second
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	secs := int(d.Seconds()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, secs)
+}
+
+func formatBytes(length int64) string {
+	const unit = 1024
+	if length < unit {
+		return fmt.Sprintf("%d B", length)
+	}
+	value := float64(length)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// Lists the podcast episodes from the feeds the user follows
+func handlerPodcasts(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	podcastCmd := flag.NewFlagSet("podcasts", flag.ExitOnError)
+	limit := podcastCmd.Int("limit", 10, "Number of episodes to show")
+	feedURL := podcastCmd.String("feed", "", "Only show episodes from this feed url")
+	podcastCmd.Parse(cmd.args)
+
+	episodes, err := s.db.GetPodcastEpisodesForUser(ctx, database.GetPodcastEpisodesForUserParams{
+		UserID:  user.ID,
+		FeedUrl: nullString(*feedURL),
+		Limit:   int32(*limit),
+	})
+	if err != nil {
+		fmt.Println("Error getting podcast episodes")
+		return err
+	}
+	if len(episodes) == 0 {
+		fmt.Println("No podcast episodes found")
+		return nil
+	}
+
+	for _, episode := range episodes {
+		fmt.Printf("Podcast: %v\n", episode.FeedName)
+		fmt.Printf("Title: %v\n", episode.Title)
+		if episode.Season.Valid || episode.Episode.Valid {
+			var number []string
+			if episode.Season.Valid {
+				number = append(number, fmt.Sprintf("Season %d", episode.Season.Int32))
+			}
+			if episode.Episode.Valid {
+				number = append(number, fmt.Sprintf("Episode %d", episode.Episode.Int32))
+			}
+			fmt.Printf("Episode: %v\n", strings.Join(number, ", "))
+		}
+		if episode.PublishedAt.Valid {
+			fmt.Printf("Published: %v\n", episode.PublishedAt.Time.Format(time.RFC1123))
+		}
+		if episode.Duration.Valid {
+			fmt.Printf("Duration: %v\n", formatDuration(episode.Duration.Int32))
+		}
+		if episode.Explicit.Valid && episode.Explicit.Bool {
+			fmt.Println("Explicit: yes")
+		}
+		media := episode.Url
+		if episode.MimeType.Valid {
+			media += fmt.Sprintf(" (%v", episode.MimeType.String)
+			if episode.Length.Valid {
+				media += ", " + formatBytes(episode.Length.Int64)
+			}
+			media += ")"
+		}
+		fmt.Printf("Media: %v\n", media)
+		fmt.Printf("Url: %v\n\n", episode.PostUrl)
+	}
+	return nil
+}
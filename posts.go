@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Short ids are the start of the post's uuid, they never change and are
+// almost always unique among the posts one user can see
+const shortIDLength = 8
+const minShortIDLength = 4
+
+var errPostNotFound = errors.New("No post found")
+var errAmbiguousPost = errors.New("Ambiguous post id")
+
+func shortID(id uuid.UUID) string {
+	return strings.ReplaceAll(id.String(), "-", "")[:shortIDLength]
+}
+
+// Finds the post a command refers to, either by its short id, its full id
+// or its url
+func resolvePost(ctx context.Context, s *state, user database.User, ref string) (database.Post, error) {
+	post, matches, err := lookupPost(ctx, s, user, ref, false)
+	if errors.Is(err, errAmbiguousPost) {
+		fmt.Printf("%v matches more than one post:\n", ref)
+		for _, match := range matches {
+			// Enough of the id to tell them apart
+			long := strings.ReplaceAll(match.ID.String(), "-", "")[:min(len(ref)+4, 32)]
+			fmt.Printf("  %v %v\n", long, match.Title)
+		}
+	} else if err != nil && !errors.Is(err, errPostNotFound) {
+		fmt.Println("Error getting post")
+	}
+	return post, err
+}
+
+// Looks a post up without printing anything so the API can use it too.
+// Short ids only match posts from feeds the user follows or has saved, so
+// other people's feeds can't make a handle ambiguous, and with scoped set
+// full ids and urls don't match any others either. The matches are
+// returned when a short id is ambiguous
+func lookupPost(ctx context.Context, s *state, user database.User, ref string, scoped bool) (database.Post, []database.Post, error) {
+	var post database.Post
+	var err error
+
+	id, parseErr := uuid.Parse(ref)
+	switch {
+	case parseErr == nil && scoped:
+		post, err = s.db.GetPostForUser(ctx, database.GetPostForUserParams{
+			ID:     id,
+			UserID: user.ID,
+		})
+	case parseErr == nil:
+		post, err = s.db.GetPost(ctx, id)
+	case isShortID(ref):
+		return lookupShortID(ctx, s, user, strings.ToLower(ref))
+	case scoped:
+		post, err = s.db.GetPostByUrlForUser(ctx, database.GetPostByUrlForUserParams{
+			Url:    ref,
+			UserID: user.ID,
+		})
+	default:
+		post, err = s.db.GetPostByUrl(ctx, ref)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Post{}, nil, fmt.Errorf("%w for %v", errPostNotFound, ref)
+	} else if err != nil {
+		return database.Post{}, nil, err
+	}
+	return post, nil, nil
+}
+
+var relativeDate = regexp.MustCompile(`^(\d+)([hdw])$`)
+
+// Dates given on the command line, either a day, a full timestamp or a time
+// relative to now such as 2d for two days ago
+func parseDateArg(value string) (time.Time, error) {
+	match := relativeDate.FindStringSubmatch(strings.ToLower(value))
+	if match != nil {
+		count, _ := strconv.Atoi(match[1])
+		unit := map[string]time.Duration{
+			"h": time.Hour,
+			"d": 24 * time.Hour,
+			"w": 7 * 24 * time.Hour,
+		}[match[2]]
+		return time.Now().Add(-time.Duration(count) * unit), nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04"} {
+		parsed, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognised date %v, use YYYY-MM-DD or a time ago like 2d", value)
+}
+
+func isShortID(ref string) bool {
+	if len(ref) < minShortIDLength || len(ref) > 32 {
+		return false
+	}
+	for _, r := range strings.ToLower(ref) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func lookupShortID(ctx context.Context, s *state, user database.User, prefix string) (database.Post, []database.Post, error) {
+	posts, err := s.db.GetPostsByIDPrefix(ctx, database.GetPostsByIDPrefixParams{
+		Prefix: prefix,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return database.Post{}, nil, err
+	}
+
+	switch len(posts) {
+	case 0:
+		return database.Post{}, nil, fmt.Errorf("%w for %v", errPostNotFound, prefix)
+	case 1:
+		return posts[0], nil, nil
+	}
+	return database.Post{}, posts, fmt.Errorf("%w %v, use more characters", errAmbiguousPost, prefix)
+}
+
+// Opens the post's link in the browser and marks it as read
+func handlerOpen(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No post provided")
+	}
+
+	post, err := resolvePost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = openBrowser(post.Url)
+	if err != nil {
+		fmt.Println("Error opening browser")
+		return err
+	}
+	fmt.Printf("Opened: %v\n", post.Title)
+
+	err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Println("Error marking post as read")
+		return err
+	}
+	return nil
+}
+
+func openBrowser(link string) error {
+	var browser *exec.Cmd
+	if command := os.Getenv("BROWSER"); command != "" {
+		browser = exec.Command(command, link)
+	} else {
+		switch runtime.GOOS {
+		case "darwin":
+			browser = exec.Command("open", link)
+		case "windows":
+			browser = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+		default:
+			browser = exec.Command("xdg-open", link)
+		}
+	}
+	return browser.Start()
+}
+
+// Posts are listed newest first by the date they were published, falling
+// back to when they were fetched for feeds without dates
+func postDate(post database.Post) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
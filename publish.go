@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gator/internal/database"
+)
+
+// What to include in a feed written by gator, all the user's followed feeds
+// unless a folder or saved search is given
+type publishOptions struct {
+	Folder string
+	Search string
+	Limit  int
+	// Where the feed will be published, used for its id and self link
+	URL string
+}
+
+// Writes the user's timeline, a folder or a saved search as a feed that
+// other readers can subscribe to
+func handlerPublish(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	publishCmd := flag.NewFlagSet("publish", flag.ExitOnError)
+	format := publishCmd.String("format", "rss", "Format to publish in, rss or atom")
+	folder := publishCmd.String("folder", "", "Only publish posts from feeds in this folder")
+	search := publishCmd.String("search", "", "Only publish posts matching this saved search")
+	limit := publishCmd.Int("limit", 50, "Number of posts to publish")
+	url := publishCmd.String("url", "http://localhost:8080/", "Address the feed will be published at")
+	output := publishCmd.String("o", "", "File to write to instead of stdout")
+	publishCmd.Parse(cmd.args)
+
+	data, err := publishFeed(ctx, s, user, *format, publishOptions{
+		Folder: *folder,
+		Search: *search,
+		Limit:  *limit,
+		URL:    *url,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	err = os.WriteFile(*output, data, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing feed file")
+		return err
+	}
+	fmt.Printf("Published feed to %v\n", *output)
+	return nil
+}
+
+// Returns the feed as an XML document in the given format
+func publishFeed(ctx context.Context, s *state, user database.User, format string, options publishOptions) ([]byte, error) {
+	if format != "rss" && format != "atom" {
+		return nil, fmt.Errorf("Unsupported feed format %v, use rss or atom", format)
+	}
+
+	feed, err := buildFeed(ctx, s, user, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any = feed
+	if format == "atom" {
+		doc = rssToAtom(feed, options.URL)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing feed")
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+func buildFeed(ctx context.Context, s *state, user database.User, options publishOptions) (*RSSFeed, error) {
+	params := database.GetPostsForUserParams{
+		UserID: user.ID,
+		Folder: nullString(options.Folder),
+		Limit:  int32(options.Limit),
+	}
+	title := fmt.Sprintf("%v's feeds in gator", user.Name)
+	if options.Folder != "" {
+		title = fmt.Sprintf("%v's %v feeds in gator", user.Name, options.Folder)
+	}
+	if options.Search != "" {
+		query, err := savedSearchQuery(ctx, s, user, options.Search)
+		if err != nil {
+			return nil, err
+		}
+		params.Search = sql.NullString{String: query, Valid: true}
+		title = fmt.Sprintf("%v's %v search in gator", user.Name, options.Search)
+	}
+
+	posts, err := s.db.GetPostsForUser(ctx, params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error getting user post params")
+		return nil, err
+	}
+
+	feed := &RSSFeed{
+		XMLName: xml.Name{Local: "rss"},
+		Version: "2.0",
+	}
+	feed.Channel.Title = title
+	feed.Channel.Link = options.URL
+	feed.Channel.Description = title
+	feed.Channel.Generator = "gator"
+	feed.Channel.LastBuildDate = time.Now().Format(time.RFC1123Z)
+	if options.URL != "" {
+		feed.Channel.AtomLinks = []AtomLink{{Href: options.URL, Rel: "self", Type: "application/rss+xml"}}
+	}
+
+	for _, post := range posts {
+		authors, err := s.db.GetAuthorsForPost(ctx, post.ID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error getting post authors")
+			return nil, err
+		}
+		categories, err := s.db.GetCategoriesForPost(ctx, post.ID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error getting post categories")
+			return nil, err
+		}
+
+		date := post.CreatedAt
+		if post.PublishedAt.Valid {
+			date = post.PublishedAt.Time
+		}
+		feed.Channel.Item = append(feed.Channel.Item, RSSItem{
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: post.Description.String,
+			Content:     post.Content.String,
+			PubDate:     date.Format(time.RFC1123Z),
+			// Post ids never change, unlike the url a feed gives a post
+			GUID:       &RSSGUID{IsPermaLink: "false", Value: "urn:uuid:" + post.ID.String()},
+			Creators:   authors,
+			Categories: categories,
+		})
+	}
+	return feed, nil
+}
+
+// The reverse of atomToRSS, for publishing gator's own feeds as Atom
+func rssToAtom(feed *RSSFeed, selfURL string) *AtomFeed {
+	atom := &AtomFeed{
+		ID:        selfURL,
+		Title:     AtomText{Text: feed.Channel.Title},
+		Updated:   time.Now().Format(time.RFC3339),
+		Authors:   []AtomPerson{{Name: "gator"}},
+		Generator: feed.Channel.Generator,
+	}
+	if atom.ID == "" {
+		atom.ID = "urn:gator:feed"
+	}
+	if selfURL != "" {
+		atom.Links = append(atom.Links, AtomLink{Href: selfURL, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	for _, item := range feed.Channel.Item {
+		date := time.Now()
+		parsed, err := parseFeedDate(item.PubDate)
+		if err == nil {
+			date = parsed
+		}
+		entry := AtomEntry{
+			Title:     AtomText{Text: item.Title},
+			ID:        item.GUID.Value,
+			Links:     []AtomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Published: date.Format(time.RFC3339),
+			Updated:   date.Format(time.RFC3339),
+			Summary:   AtomText{Type: "html", Text: item.Description},
+			Content:   AtomText{Type: "html", Text: item.Content},
+		}
+		for _, name := range item.Creators {
+			entry.Authors = append(entry.Authors, AtomPerson{Name: name})
+		}
+		for _, name := range item.Categories {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: name})
+		}
+		atom.Entry = append(atom.Entry, entry)
+	}
+	if len(atom.Entry) > 0 {
+		atom.Updated = atom.Entry[0].Updated
+	}
+	return atom
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// Published feeds are piped to a file, so stdout has to be the feed alone
+func TestPublishStdout(t *testing.T) {
+	for _, format := range []string{"rss", "atom"} {
+		t.Run(format, func(t *testing.T) {
+			s, mock := newCommandTest(t)
+			mock.ExpectQuery("GetPostsForUser").WillReturnRows(sqlmock.NewRows([]string{
+				"id", "created_at", "updated_at", "title", "url", "description", "published_at", "feed_id",
+				"content", "episode", "season", "explicit", "image_url", "search_vector", "item_id",
+				"feed_name", "user_name", "read", "saved",
+			}).AddRow(
+				readerPostID[0].String(), readerCreated, readerCreated, "Post 1", "https://blog.example.com/1", "Summary", readerCreated, readerFeedID.String(),
+				"<p>Post 1</p>", nil, nil, nil, nil, nil, int64(1),
+				"Example Blog", "alice", false, false,
+			))
+			mock.ExpectQuery("GetAuthorsForPost").WithArgs(readerPostID[0].String()).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Ann Author"))
+			mock.ExpectQuery("GetCategoriesForPost").WithArgs(readerPostID[0].String()).
+				WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+			out := runStdout(t, s, "publish", handlerPublish, "--format", format)
+			if !strings.HasPrefix(out, xml.Header) {
+				t.Fatalf("stdout = %q, want it to start with the XML header", out)
+			}
+			decoder := xml.NewDecoder(strings.NewReader(strings.TrimSpace(out)))
+			var root struct{ XMLName xml.Name }
+			err := decoder.Decode(&root)
+			if err != nil {
+				t.Fatalf("stdout isn't a feed: %v\n%v", err, out)
+			}
+			if want := map[string]string{"rss": "rss", "atom": "feed"}[format]; root.XMLName.Local != want {
+				t.Errorf("root = %v, want %v", root.XMLName.Local, want)
+			}
+			if _, err := decoder.Token(); err == nil {
+				t.Errorf("stdout = %q, want nothing after the feed", out)
+			}
+			if !strings.Contains(out, "https://blog.example.com/1") {
+				t.Errorf("stdout = %q, want the post", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	"gator/internal/database"
+)
+
+func handlerRead(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No post provided")
+	}
+
+	for _, ref := range cmd.args {
+		post, err := resolvePost(ctx, s, user, ref)
+		if err != nil {
+			return err
+		}
+		err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			ReadAt: time.Now(),
+		})
+		if err != nil {
+			fmt.Println("Error marking post as read")
+			return err
+		}
+		fmt.Printf("Marked as read: %v\n", post.Title)
+	}
+	return nil
+}
+
+func handlerMarkAllRead(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	markCmd := flag.NewFlagSet("markallread", flag.ExitOnError)
+	feedURL := markCmd.String("feed", "", "Only mark posts from this feed url")
+	before := markCmd.String("before", "", "Only mark posts published before this date")
+	folder := markCmd.String("folder", "", "Only mark posts from feeds in this folder")
+	markCmd.Parse(cmd.args)
+
+	params := database.MarkAllPostsReadParams{
+		ReadAt:  time.Now(),
+		UserID:  user.ID,
+		FeedUrl: nullString(*feedURL),
+		Folder:  nullString(*folder),
+	}
+	if *before != "" {
+		beforeTime, err := parseDateArg(*before)
+		if err != nil {
+			return err
+		}
+		params.Before = sql.NullTime{Time: beforeTime, Valid: true}
+	}
+
+	marked, err := s.db.MarkAllPostsRead(ctx, params)
+	if err != nil {
+		fmt.Println("Error marking posts as read")
+		return err
+	}
+	fmt.Printf("Marked %v posts as read\n", marked)
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"encoding/xml"
 	"io"
@@ -10,45 +11,115 @@ import (
 	"time"
 	"github.com/google/uuid"
 	"gator/internal/database"
+	"gator/internal/htmltext"
 	"database/sql"
 	"log"
+	"strings"
 )
 
+// AtomLinks is listed before Link so <atom:link rel="self"> doesn't overwrite it
+// XMLName has no tag so any root element is accepted when fetching, it is set
+// to rss when gator writes a feed of its own
 type RSSFeed struct {
+	XMLName xml.Name
+	Version string `xml:"version,attr,omitempty"`
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title         string     `xml:"title"`
+		AtomLinks     []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link          string     `xml:"link"`
+		Description   string     `xml:"description"`
+		Language      string     `xml:"language,omitempty"`
+		Image         RSSImage   `xml:"image"`
+		Generator     string     `xml:"generator,omitempty"`
+		Copyright     string     `xml:"copyright,omitempty"`
+		LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+		Item          []RSSItem  `xml:"item"`
 	} `xml:"channel"`
 }
 
+// Channel logo, the link is where the image points to when clicked
+type RSSImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded,omitempty"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	GUID        *RSSGUID `xml:"guid"`
+
+	// RSS author is usually an email address, Dublin Core creator a name
+	Author     string   `xml:"author,omitempty"`
+	Creators   []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories []string `xml:"category"`
+
+	// Podcast episodes and other attached media
+	Enclosures     []RSSEnclosure `xml:"enclosure"`
+	Media          []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	ItunesDuration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration,omitempty"`
+	ItunesEpisode  string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode,omitempty"`
+	ItunesSeason   string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season,omitempty"`
+	ItunesExplicit string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit,omitempty"`
+	ItunesImage    ItunesImage    `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+type RSSGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr,omitempty"`
+	Value       string `xml:",chardata"`
+}
+
+// Empty images are left out when gator writes a feed
+func (i RSSImage) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if i.URL == "" {
+		return nil
+	}
+	type image RSSImage
+	return e.EncodeElement(image(i), start)
+}
+
+// Shared by feed fetching and media downloads so both behave the same
+// There is no overall timeout as media files can take a while to download
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// Prepares a GET request with the gator User-Agent
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("User-Agent", "Gator")
+	return request, nil
 }
 
 func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	// Fetch feed time!
 
 	// NewRequestWithContex - prepares the request to send with clientDo
-	request, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	request, err := newRequest(ctx, feedURL)
 	if err != nil {
 		fmt.Println("Error doing New Request With Context")
 		return &RSSFeed{}, err
 	}
 
-	// something about setting the header to gator
-	request.Header.Set("User-Agent", "Gator")
 	// Client DO sends a HTTP request and returns a HTTP response
 
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := httpClient.Do(request)
 	if err != nil {
 		fmt.Println("Error sending Client Do request/response")
 		return &RSSFeed{}, err
 	}
+	defer resp.Body.Close()
 
 	// Read the response from *http.Response 
 	f, err := io.ReadAll(resp.Body)
@@ -64,11 +135,13 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 	}
 		
 	// Unescape the titles and descriptions here
+	// Content is left alone, it is already html once the XML is decoded
 	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
 	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
 	for i := range feed.Channel.Item {
 		feed.Channel.Item[i].Title = html.UnescapeString(feed.Channel.Item[i].Title)
 		feed.Channel.Item[i].Description = html.UnescapeString(feed.Channel.Item[i].Description)
+		feed.Channel.Item[i].Content = strings.TrimSpace(feed.Channel.Item[i].Content)
 	}
 	
 	return feed, nil
@@ -82,6 +155,16 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 func xmlUnmarshall(xmlItem []byte) (*RSSFeed, error) {
 	// Function to do the xmlUnmarshalling
 	// Returns a RSSFeed pointer?
+	if isAtomFeed(xmlItem) {
+		atom := &AtomFeed{}
+		err := xml.Unmarshal(xmlItem, atom)
+		if err != nil {
+			fmt.Println("Error unmarshalling Atom feed")
+			return &RSSFeed{}, err
+		}
+		return atomToRSS(atom), nil
+	}
+
 	feed := &RSSFeed{}
 	err := xml.Unmarshal(xmlItem, feed)
 	if err != nil {
@@ -91,6 +174,20 @@ func xmlUnmarshall(xmlItem []byte) (*RSSFeed, error) {
 	return feed, nil
 }
 
+// Atom documents have <feed> as the root element instead of <rss>
+func isAtomFeed(xmlItem []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(xmlItem))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "feed"
+		}
+	}
+}
+
 
 func scrapeFeeds(s *state) error {
 	ctx := context.Background()
@@ -164,6 +261,14 @@ func scrapeFeeds(s *state) error {
 		fmt.Println("Error fetching feed")
 		return err
 	}
+	normalizeFeedLinks(response, feed.Url, s.config.TrackingParameters())
+
+	// Refresh the channel metadata every time the feed is fetched
+	err = s.db.UpdateFeedMetadata(ctx, feedMetadataParams(feedID, response))
+	if err != nil {
+		fmt.Println("Error updating feed metadata")
+		return err
+	}
 	
 	for i := range response.Channel.Item {
 		fmt.Printf("Title: %v : %v\n", response.Channel.Title, response.Channel.Item[i].Title)
@@ -173,24 +278,39 @@ func scrapeFeeds(s *state) error {
 
 	for i := range response.Channel.Item {
 
+		// Each post is keyed on its own link, items without one can't be stored
+		if response.Channel.Item[i].Link == "" {
+			fmt.Printf("No link for %v, skipping...\n", response.Channel.Item[i].Title)
+			continue
+		}
+
 		newPost := database.CreatePostsParams{
 		ID: uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Title: response.Channel.Item[i].Title,
-		Url: feed.Url,
+		Url: response.Channel.Item[i].Link,
 		FeedID: feedID,
 		}
 	
 		// Handle description conditionally
-		if response.Channel.Item[i].Description != "" {
-			newPost.Description = sql.NullString{String: response.Channel.Item[i].Description, Valid: true}
+		// Post bodies are stored as sanitized html
+		description := htmltext.Sanitize(response.Channel.Item[i].Description)
+		if description != "" {
+			newPost.Description = sql.NullString{String: description, Valid: true}
 		} else {
 			newPost.Description = sql.NullString{Valid: false}
 		}
+		// The full article body, the description is kept as the summary
+		newPost.Content = nullString(htmltext.Sanitize(response.Channel.Item[i].Content))
+		// iTunes episode details, only set for podcast feeds
+		newPost.Episode = parseItunesNumber(response.Channel.Item[i].ItunesEpisode)
+		newPost.Season = parseItunesNumber(response.Channel.Item[i].ItunesSeason)
+		newPost.Explicit = parseItunesExplicit(response.Channel.Item[i].ItunesExplicit)
+		newPost.ImageUrl = nullString(response.Channel.Item[i].ItunesImage.Href)
 		// Check and populate the published date
 		if response.Channel.Item[i].PubDate != "" {
-			pubTime, err := time.Parse(time.RFC822, response.Channel.Item[i].PubDate)
+			pubTime, err := parseFeedDate(response.Channel.Item[i].PubDate)
 			if err != nil {
 			fmt.Println("Error parsing time, published time will be set to null")
 			newPost.PublishedAt = sql.NullTime{Valid: false}
@@ -199,11 +319,26 @@ func scrapeFeeds(s *state) error {
 			}
 	} 
 
-		err := s.db.CreatePosts(ctx, newPost)
+		postID, err := s.db.CreatePosts(ctx, newPost)
 		if err != nil {
 			log.Printf("Error returned from CreatePosts: %v\n", err)
 			return err
 		}
+
+		err = savePostEnclosures(ctx, s, postID, response.Channel.Item[i])
+		if err != nil {
+			return err
+		}
+
+		err = savePostAuthors(ctx, s, postID, response.Channel.Item[i])
+		if err != nil {
+			return err
+		}
+
+		err = savePostCategories(ctx, s, postID, response.Channel.Item[i])
+		if err != nil {
+			return err
+		}
 		fmt.Printf("New post saved to database\n")
 
 	}
@@ -213,3 +348,61 @@ func scrapeFeeds(s *state) error {
 }
 
 
+// Builds the metadata update for a feed row from the fetched channel
+func feedMetadataParams(feedID uuid.UUID, feed *RSSFeed) database.UpdateFeedMetadataParams {
+	channel := feed.Channel
+	params := database.UpdateFeedMetadataParams{
+		ID:          feedID,
+		UpdatedAt:   time.Now(),
+		Title:       nullString(channel.Title),
+		SiteLink:    nullString(channel.Link),
+		Description: nullString(channel.Description),
+		Language:    nullString(channel.Language),
+		ImageUrl:    nullString(channel.Image.URL),
+		Generator:   nullString(channel.Generator),
+		Copyright:   nullString(channel.Copyright),
+	}
+	if channel.LastBuildDate != "" {
+		buildTime, err := parseFeedDate(channel.LastBuildDate)
+		if err != nil {
+			fmt.Println("Error parsing last build date, it will be set to null")
+		} else {
+			params.LastBuildDate = sql.NullTime{Time: buildTime, Valid: true}
+		}
+	}
+	return params
+}
+
+// Feeds in the wild use a handful of date formats, try each of them in turn
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseFeedDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date format: %s", value)
+}
+
+// Empty strings are stored as NULL rather than ""
+func nullString(value string) sql.NullString {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullString{Valid: false}
+	}
+	return sql.NullString{String: value, Valid: true}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+)
+
+// Saved posts are kept on a reading list, their media is never pruned
+func handlerSave(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	saveCmd := flag.NewFlagSet("save", flag.ExitOnError)
+	note := saveCmd.String("note", "", "A note to keep with the post")
+	args := parseFlags(saveCmd, cmd.args)
+
+	if len(args) == 0 {
+		return fmt.Errorf("No post provided")
+	}
+
+	post, err := resolvePost(ctx, s, user, args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SavePost(ctx, database.SavePostParams{
+		UserID:    user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Note:      nullString(*note),
+	})
+	if err != nil {
+		fmt.Println("Error saving post")
+		return err
+	}
+	fmt.Printf("Saved: %v\n", post.Title)
+	return nil
+}
+
+func handlerUnsave(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No post provided")
+	}
+
+	post, err := resolvePost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.db.UnsavePost(ctx, database.UnsavePostParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		fmt.Println("Error removing saved post")
+		return err
+	}
+	if removed == 0 {
+		fmt.Printf("Post wasn't saved: %v\n", post.Title)
+		return nil
+	}
+	fmt.Printf("Removed from saved posts: %v\n", post.Title)
+	return nil
+}
+
+func handlerSaved(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	savedCmd := flag.NewFlagSet("saved", flag.ExitOnError)
+	limit := savedCmd.Int("limit", 20, "Number of saved posts to show")
+	feed := savedCmd.String("feed", "", "Only show posts from this feed, by name or url")
+	keyword := savedCmd.String("keyword", "", "Only show posts with this in the title or note")
+	since := savedCmd.String("since", "", "Only show posts saved since this date")
+	savedCmd.Parse(cmd.args)
+
+	params := database.GetSavedPostsForUserParams{
+		UserID:  user.ID,
+		Feed:    nullString(*feed),
+		Keyword: nullString(*keyword),
+		Limit:   int32(*limit),
+	}
+	if *since != "" {
+		sinceTime, err := parseDateArg(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: sinceTime, Valid: true}
+	}
+
+	saved, err := s.db.GetSavedPostsForUser(ctx, params)
+	if err != nil {
+		fmt.Println("Error getting saved posts")
+		return err
+	}
+	if len(saved) == 0 {
+		fmt.Println("No saved posts found")
+		return nil
+	}
+
+	for _, post := range saved {
+		fmt.Printf("ID: %v\n", shortID(post.ID))
+		fmt.Printf("Title: %v\n", post.Title)
+		fmt.Printf("Feed: %v\n", post.FeedName)
+		fmt.Printf("Url: %v\n", post.Url)
+		fmt.Printf("Saved: %v\n", post.SavedAt.Format("2006-01-02"))
+		if post.Note.Valid {
+			fmt.Printf("Note: %v\n", strings.TrimSpace(post.Note.String))
+		}
+		fmt.Println()
+	}
+	return nil
+}
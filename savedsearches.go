@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Saved searches work like feeds made up of every followed post that
+// matches the query, see browse --search
+func handlerSaveSearch(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	saveCmd := flag.NewFlagSet("savesearch", flag.ExitOnError)
+	name := saveCmd.String("name", "", "Name to browse the search by")
+	args := parseFlags(saveCmd, cmd.args)
+
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		return fmt.Errorf("No search query provided")
+	}
+	if *name == "" {
+		return fmt.Errorf("No name provided, use --name")
+	}
+
+	search, err := s.db.CreateSavedSearch(ctx, database.CreateSavedSearchParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      *name,
+		Query:     query,
+	})
+	if err != nil {
+		fmt.Println("Error saving search")
+		return err
+	}
+	fmt.Printf("Saved search %v: %v\n", search.Name, search.Query)
+	return nil
+}
+
+func handlerSearches(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	searches, err := s.db.GetSavedSearchesForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Println("Error getting saved searches")
+		return err
+	}
+	if len(searches) == 0 {
+		fmt.Println("No saved searches found")
+		return nil
+	}
+
+	for _, search := range searches {
+		fmt.Printf("%v: %v (%v unread)\n", search.Name, search.Query, search.UnreadCount)
+	}
+	return nil
+}
+
+func handlerDeleteSearch(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No search name provided")
+	}
+
+	removed, err := s.db.DeleteSavedSearch(ctx, database.DeleteSavedSearchParams{
+		UserID: user.ID,
+		Name:   cmd.args[0],
+	})
+	if err != nil {
+		fmt.Println("Error deleting saved search")
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("No saved search called %v", cmd.args[0])
+	}
+	fmt.Printf("Deleted saved search %v\n", cmd.args[0])
+	return nil
+}
+
+func savedSearchQuery(ctx context.Context, s *state, user database.User, name string) (string, error) {
+	search, err := s.db.GetSavedSearch(ctx, database.GetSavedSearchParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("No saved search called %v", name)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Error getting saved search")
+		return "", err
+	}
+	return search.Query, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"gator/internal/database"
+
+	"golang.org/x/term"
+)
+
+// Searches post titles and bodies, quoted phrases, or and -word are supported
+func handlerSearch(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	limit := searchCmd.Int("limit", 10, "Number of results to show")
+	all := searchCmd.Bool("all", false, "Search posts from every feed, not just followed ones")
+	since := searchCmd.String("since", "", "Only search posts published since this date, or a time ago like 4w")
+	args := parseFlags(searchCmd, cmd.args)
+
+	query := strings.TrimSpace(strings.Join(args, " "))
+	if query == "" {
+		return fmt.Errorf("No search query provided")
+	}
+
+	params := database.SearchPostsParams{
+		Query:    query,
+		AllFeeds: *all,
+		UserID:   user.ID,
+		Limit:    int32(*limit),
+	}
+	if *since != "" {
+		sinceTime, err := parseDateArg(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: sinceTime, Valid: true}
+	}
+
+	results, err := s.db.SearchPosts(ctx, params)
+	if err != nil {
+		fmt.Println("Error searching posts")
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("No posts found")
+		return nil
+	}
+
+	for _, result := range results {
+		fmt.Printf("ID: %v\n", shortID(result.ID))
+		fmt.Printf("Title: %v\n", result.Title)
+		fmt.Printf("Feed: %v\n", result.FeedName)
+		fmt.Printf("Published: %v\n", displayNullTime(result.PublishedAt))
+		fmt.Printf("Url: %v\n", result.Url)
+		if snippet := formatSnippet(result.Snippet); snippet != "" {
+			fmt.Printf("%v\n", snippet)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// Matches come back wrapped in <b> tags, they're shown in bold on a
+// terminal and between asterisks otherwise
+func formatSnippet(snippet string) string {
+	start, end := "**", "**"
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		start, end = "\033[1m", "\033[0m"
+	}
+	snippet = strings.NewReplacer("<b>", start, "</b>", end).Replace(snippet)
+	snippet = strings.Join(strings.Fields(html.UnescapeString(snippet)), " ")
+	if snippet == "" {
+		return ""
+	}
+	return "... " + snippet + " ..."
+}
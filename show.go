@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gator/internal/database"
+	"gator/internal/htmltext"
+
+	"golang.org/x/term"
+)
+
+// Shows one post in full and marks it as read
+func handlerShow(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No post provided")
+	}
+
+	post, err := resolvePost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	// The user's own title for the feed if they renamed it
+	feedName, err := s.db.GetFeedNameForUser(ctx, database.GetFeedNameForUserParams{
+		UserID: user.ID,
+		FeedID: post.FeedID,
+	})
+	if err != nil {
+		fmt.Println("Error getting Feed Details")
+		return err
+	}
+	authors, err := s.db.GetAuthorsForPost(ctx, post.ID)
+	if err != nil {
+		fmt.Println("Error getting post authors")
+		return err
+	}
+	categories, err := s.db.GetCategoriesForPost(ctx, post.ID)
+	if err != nil {
+		fmt.Println("Error getting post categories")
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v\n\n", post.Title)
+	fmt.Fprintf(&b, "ID: %v\n", shortID(post.ID))
+	fmt.Fprintf(&b, "Feed: %v\n", feedName)
+	if len(authors) > 0 {
+		fmt.Fprintf(&b, "Author: %v\n", strings.Join(authors, ", "))
+	}
+	fmt.Fprintf(&b, "Published: %v\n", displayNullTime(post.PublishedAt))
+	fmt.Fprintf(&b, "Link: %v\n", post.Url)
+	if len(categories) > 0 {
+		fmt.Fprintf(&b, "Categories: %v\n", strings.Join(categories, ", "))
+	}
+	body := htmltext.Render(postBody(post.Content, post.Description), terminalWidth())
+	if body != "" {
+		fmt.Fprintf(&b, "\n%v\n", body)
+	}
+
+	err = page(b.String())
+	if err != nil {
+		return err
+	}
+
+	err = s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Println("Error marking post as read")
+		return err
+	}
+	return nil
+}
+
+// Sends text through $PAGER when writing to a terminal, falling back to
+// less, or prints it straight out when the output is piped somewhere
+func page(text string) error {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print(text)
+		return nil
+	}
+
+	// PAGER often carries options, such as "less -R"
+	fields := strings.Fields(os.Getenv("PAGER"))
+	if len(fields) == 0 {
+		fields = []string{"less"}
+	}
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		fmt.Print(text)
+		return nil
+	}
+
+	pagerCmd := exec.Command(path, fields[1:]...)
+	pagerCmd.Stdin = strings.NewReader(text)
+	pagerCmd.Stdout = os.Stdout
+	pagerCmd.Stderr = os.Stderr
+	return pagerCmd.Run()
+}
@@ -0,0 +1,32 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, created_at, updated_at, user_id, name, token_hash, scope)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetAPITokensForUser :many
+SELECT * FROM api_tokens
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: GetUserByAPIToken :one
+SELECT users.*, api_tokens.id AS token_id, api_tokens.scope
+FROM api_tokens
+INNER JOIN users ON api_tokens.user_id = users.id
+WHERE api_tokens.token_hash = $1;
+
+-- name: MarkAPITokenUsed :exec
+UPDATE api_tokens
+SET last_used_at = $2
+WHERE id = $1;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens
+WHERE user_id = $1 AND name = $2;
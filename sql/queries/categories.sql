@@ -0,0 +1,25 @@
+-- name: CreateCategory :one
+INSERT INTO categories (id, created_at, updated_at, name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: CreatePostCategory :exec
+INSERT INTO post_categories (post_id, category_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetCategoriesForPost :many
+SELECT categories.name FROM categories
+JOIN post_categories ON post_categories.category_id = categories.id
+WHERE post_categories.post_id = $1
+ORDER BY categories.name;
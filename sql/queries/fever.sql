@@ -0,0 +1,76 @@
+-- name: GetUserByFeverKey :one
+SELECT * FROM users
+WHERE fever_api_key = $1;
+
+-- name: SetUserFeverKey :exec
+UPDATE users
+SET updated_at = $2, fever_api_key = $3
+WHERE id = $1;
+
+-- name: GetFeverFeeds :many
+SELECT
+    feeds.fever_id,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS title,
+    feeds.url,
+    feeds.site_link,
+    feeds.last_fetched_at,
+    folders.fever_id AS group_id
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.fever_id;
+
+-- name: GetFeverItems :many
+SELECT
+    posts.item_id,
+    feeds.fever_id AS feed_id,
+    posts.title,
+    COALESCE((
+        SELECT string_agg(authors.name, ', ' ORDER BY authors.name) FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+    ), '')::text AS authors,
+    posts.url,
+    posts.description,
+    posts.content,
+    COALESCE(posts.published_at, posts.created_at)::timestamp AS published,
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ) AS saved
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+WHERE (cardinality(sqlc.arg('with_ids')::bigint[]) = 0 OR posts.item_id = ANY(sqlc.arg('with_ids')::bigint[]))
+    AND (sqlc.narg('since_id')::bigint IS NULL OR posts.item_id > sqlc.narg('since_id')::bigint)
+    AND (sqlc.narg('max_id')::bigint IS NULL OR posts.item_id < sqlc.narg('max_id')::bigint)
+ORDER BY
+    CASE WHEN sqlc.arg('newest_first')::boolean THEN posts.item_id END DESC,
+    posts.item_id
+LIMIT sqlc.arg('limit');
+
+-- name: CountFeverItems :one
+SELECT count(*) FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1;
+
+-- name: GetUnreadItemIDs :many
+SELECT posts.item_id FROM posts
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+    AND NOT EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    )
+ORDER BY posts.item_id;
+
+-- name: GetSavedItemIDs :many
+SELECT posts.item_id FROM saved_posts
+INNER JOIN posts ON saved_posts.post_id = posts.id
+WHERE saved_posts.user_id = $1
+ORDER BY posts.item_id;
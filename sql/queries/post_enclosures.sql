@@ -0,0 +1,80 @@
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (id, created_at, updated_at, post_id, url, mime_type, length, duration)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+ON CONFLICT (post_id, url) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    mime_type = EXCLUDED.mime_type,
+    length = EXCLUDED.length,
+    duration = EXCLUDED.duration;
+
+-- name: GetPodcastEpisodesForUser :many
+SELECT
+    post_enclosures.id,
+    post_enclosures.url,
+    post_enclosures.mime_type,
+    post_enclosures.length,
+    post_enclosures.duration,
+    posts.title,
+    posts.url AS post_url,
+    posts.published_at,
+    posts.episode,
+    posts.season,
+    posts.explicit,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name
+FROM post_enclosures
+INNER JOIN posts ON post_enclosures.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url')::text)
+ORDER BY posts.published_at DESC NULLS LAST, posts.created_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetEnclosuresForDownload :many
+SELECT
+    post_enclosures.id,
+    post_enclosures.url,
+    post_enclosures.mime_type,
+    post_enclosures.length,
+    post_enclosures.local_path,
+    post_enclosures.local_size,
+    posts.title,
+    posts.published_at,
+    posts.created_at,
+    feeds.id AS feed_id,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    feeds.name AS feed_dir,
+    EXISTS (
+        SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id
+    ) AS saved
+FROM post_enclosures
+INNER JOIN posts ON post_enclosures.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = feeds.id AND feed_follows.user_id = sqlc.narg('user_id')::uuid
+WHERE (sqlc.narg('feed_id')::uuid IS NULL OR feeds.id = sqlc.narg('feed_id')::uuid)
+    AND (sqlc.narg('user_id')::uuid IS NULL OR feed_follows.id IS NOT NULL)
+ORDER BY feeds.id, COALESCE(posts.published_at, posts.created_at) DESC;
+
+-- name: MarkEnclosureDownloaded :exec
+UPDATE post_enclosures
+SET updated_at = $2, local_path = $3, local_size = $4, downloaded_at = $5
+WHERE id = $1;
+
+-- name: ClearEnclosureDownload :exec
+UPDATE post_enclosures
+SET updated_at = $2, local_path = NULL, local_size = NULL, downloaded_at = NULL
+WHERE id = $1;
+
+-- name: GetDownloadedSize :one
+SELECT COALESCE(SUM(local_size), 0)::bigint AS total
+FROM post_enclosures
+WHERE local_path IS NOT NULL;
@@ -0,0 +1,28 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (user_id, post_id, read_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkAllPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, sqlc.arg('read_at')::timestamp
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('feed_url')::text IS NULL OR feeds.url = sqlc.narg('feed_url')::text)
+    AND (sqlc.narg('before')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg('before')::timestamp)
+    AND (sqlc.narg('folder')::text IS NULL OR feed_follows.folder_id IN (
+        SELECT folders.id FROM folders
+        WHERE folders.user_id = feed_follows.user_id AND folders.name = sqlc.narg('folder')::text
+    ))
+    AND (sqlc.narg('created_until')::timestamp IS NULL OR posts.created_at <= sqlc.narg('created_until')::timestamp)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
@@ -0,0 +1,102 @@
+-- name: GetPost :one
+SELECT * FROM posts
+WHERE id = $1;
+
+-- name: GetPostByUrl :one
+SELECT * FROM posts
+WHERE url = $1;
+
+-- name: GetPostForUser :one
+SELECT * FROM posts
+WHERE posts.id = sqlc.arg('id')
+    AND (
+        posts.feed_id IN (
+            SELECT feed_follows.feed_id FROM feed_follows
+            WHERE feed_follows.user_id = sqlc.arg('user_id')
+        )
+        OR posts.id IN (
+            SELECT saved_posts.post_id FROM saved_posts
+            WHERE saved_posts.user_id = sqlc.arg('user_id')
+        )
+    );
+
+-- name: GetPostByUrlForUser :one
+SELECT * FROM posts
+WHERE posts.url = sqlc.arg('url')
+    AND (
+        posts.feed_id IN (
+            SELECT feed_follows.feed_id FROM feed_follows
+            WHERE feed_follows.user_id = sqlc.arg('user_id')
+        )
+        OR posts.id IN (
+            SELECT saved_posts.post_id FROM saved_posts
+            WHERE saved_posts.user_id = sqlc.arg('user_id')
+        )
+    );
+
+-- name: GetPostsByIDPrefix :many
+SELECT * FROM posts
+WHERE replace(posts.id::text, '-', '') LIKE sqlc.arg('prefix')::text || '%'
+    AND (
+        posts.feed_id IN (
+            SELECT feed_follows.feed_id FROM feed_follows
+            WHERE feed_follows.user_id = sqlc.arg('user_id')
+        )
+        OR posts.id IN (
+            SELECT saved_posts.post_id FROM saved_posts
+            WHERE saved_posts.user_id = sqlc.arg('user_id')
+        )
+    )
+ORDER BY posts.created_at DESC
+LIMIT 10;
+
+-- name: GetPostsForExport :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.content,
+    posts.published_at,
+    posts.created_at,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    feeds.url AS feed_url,
+    COALESCE((
+        SELECT string_agg(authors.name, E'\n' ORDER BY authors.name) FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+    ), '')::text AS authors,
+    COALESCE((
+        SELECT string_agg(categories.name, E'\n' ORDER BY categories.name) FROM post_categories
+        INNER JOIN categories ON post_categories.category_id = categories.id
+        WHERE post_categories.post_id = posts.id
+    ), '')::text AS categories,
+    saved_posts.post_id IS NOT NULL AS saved,
+    saved_posts.note
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN saved_posts ON saved_posts.post_id = posts.id AND saved_posts.user_id = sqlc.arg('user_id')
+WHERE (feed_follows.id IS NOT NULL OR saved_posts.post_id IS NOT NULL)
+    AND (NOT sqlc.arg('saved_only')::boolean OR saved_posts.post_id IS NOT NULL)
+    AND (sqlc.narg('feed')::text IS NULL
+        OR feeds.url = sqlc.narg('feed')::text
+        OR feeds.name ILIKE sqlc.narg('feed')::text
+        OR feed_follows.title ILIKE sqlc.narg('feed')::text)
+    AND (sqlc.narg('since')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg('since')::timestamp)
+    AND (sqlc.narg('until')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg('until')::timestamp)
+    AND (sqlc.narg('after_date')::timestamp IS NULL
+        OR (COALESCE(posts.published_at, posts.created_at), posts.id) > (sqlc.narg('after_date')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at), posts.id
+LIMIT sqlc.arg('limit');
+
+-- name: GetPostState :one
+SELECT
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = sqlc.arg('user_id') AND post_reads.post_id = sqlc.arg('post_id')
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = sqlc.arg('user_id') AND saved_posts.post_id = sqlc.arg('post_id')
+    ) AS saved;
@@ -0,0 +1,84 @@
+-- name: GetReaderItems :many
+SELECT
+    posts.id,
+    posts.item_id,
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.content,
+    posts.published_at,
+    posts.created_at,
+    posts.feed_id,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    feeds.site_link,
+    folders.name AS folder_name,
+    COALESCE((
+        SELECT string_agg(authors.name, ', ' ORDER BY authors.name) FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+    ), '')::text AS authors,
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ) AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE (sqlc.narg('feed_id')::uuid IS NULL OR posts.feed_id = sqlc.narg('feed_id')::uuid)
+    AND (sqlc.narg('folder')::text IS NULL OR folders.name = sqlc.narg('folder')::text)
+    AND (NOT sqlc.arg('starred_only')::boolean OR EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ))
+    AND (sqlc.narg('read')::boolean IS NULL OR sqlc.narg('read')::boolean = EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ))
+    AND (sqlc.narg('since')::timestamp IS NULL OR posts.created_at >= sqlc.narg('since')::timestamp)
+    AND (sqlc.narg('until')::timestamp IS NULL OR posts.created_at < sqlc.narg('until')::timestamp)
+    AND (sqlc.narg('after_item')::bigint IS NULL
+        OR (sqlc.arg('oldest_first')::boolean AND posts.item_id > sqlc.narg('after_item')::bigint)
+        OR (NOT sqlc.arg('oldest_first')::boolean AND posts.item_id < sqlc.narg('after_item')::bigint))
+ORDER BY
+    CASE WHEN sqlc.arg('oldest_first')::boolean THEN posts.item_id END,
+    posts.item_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetReaderItemsByID :many
+SELECT
+    posts.id,
+    posts.item_id,
+    posts.title,
+    posts.url,
+    posts.description,
+    posts.content,
+    posts.published_at,
+    posts.created_at,
+    posts.feed_id,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    feeds.site_link,
+    folders.name AS folder_name,
+    COALESCE((
+        SELECT string_agg(authors.name, ', ' ORDER BY authors.name) FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+    ), '')::text AS authors,
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ) AS starred
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE posts.item_id = ANY(sqlc.arg('item_ids')::bigint[])
+ORDER BY posts.item_id DESC;
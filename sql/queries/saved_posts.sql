@@ -0,0 +1,45 @@
+-- name: SavePost :exec
+INSERT INTO saved_posts (user_id, post_id, created_at, updated_at, note)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at, note = EXCLUDED.note;
+
+-- name: UnsavePost :execrows
+DELETE FROM saved_posts
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetSavedPostsForUser :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    saved_posts.note,
+    saved_posts.created_at AS saved_at
+FROM saved_posts
+INNER JOIN posts ON saved_posts.post_id = posts.id
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+WHERE saved_posts.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('feed')::text IS NULL
+        OR feeds.url = sqlc.narg('feed')::text
+        OR feeds.name ILIKE sqlc.narg('feed')::text
+        OR feed_follows.title ILIKE sqlc.narg('feed')::text)
+    AND (sqlc.narg('keyword')::text IS NULL
+        OR posts.title ILIKE '%' || sqlc.narg('keyword')::text || '%'
+        OR saved_posts.note ILIKE '%' || sqlc.narg('keyword')::text || '%')
+    AND (sqlc.narg('since')::timestamp IS NULL OR saved_posts.created_at >= sqlc.narg('since')::timestamp)
+    AND (sqlc.narg('after_id')::uuid IS NULL
+        OR (saved_posts.created_at, posts.id) < (
+            SELECT last_saved.created_at, last_saved.post_id
+            FROM saved_posts AS last_saved
+            WHERE last_saved.user_id = sqlc.arg('user_id') AND last_saved.post_id = sqlc.narg('after_id')::uuid))
+ORDER BY saved_posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
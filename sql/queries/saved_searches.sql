@@ -0,0 +1,39 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (id, created_at, updated_at, user_id, name, query)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (user_id, name) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    query = EXCLUDED.query
+RETURNING *;
+
+-- name: GetSavedSearch :one
+SELECT * FROM saved_searches
+WHERE user_id = $1 AND name = $2;
+
+-- name: GetSavedSearchesForUser :many
+SELECT
+    saved_searches.*,
+    (
+        SELECT count(*) FROM posts
+        INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+        WHERE feed_follows.user_id = saved_searches.user_id
+            AND posts.search_vector @@ websearch_to_tsquery('english', saved_searches.query)
+            AND NOT EXISTS (
+                SELECT 1 FROM post_reads
+                WHERE post_reads.user_id = saved_searches.user_id AND post_reads.post_id = posts.id
+            )
+    ) AS unread_count
+FROM saved_searches
+WHERE saved_searches.user_id = $1
+ORDER BY saved_searches.name;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE user_id = $1 AND name = $2;
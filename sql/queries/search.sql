@@ -0,0 +1,23 @@
+-- name: SearchPosts :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    posts.created_at,
+    COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name,
+    ts_rank(posts.search_vector, websearch_to_tsquery('english', sqlc.arg('query')::text)) AS rank,
+    ts_headline(
+        'english',
+        regexp_replace(coalesce(posts.content, posts.description, ''), '<[^>]*>', ' ', 'g'),
+        websearch_to_tsquery('english', sqlc.arg('query')::text),
+        'StartSel=<b>, StopSel=</b>, MaxWords=30, MinWords=10, MaxFragments=2'
+    )::text AS snippet
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg('user_id')
+WHERE posts.search_vector @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+    AND (sqlc.arg('all_feeds')::boolean OR feed_follows.id IS NOT NULL)
+    AND (sqlc.narg('since')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg('since')::timestamp)
+ORDER BY rank DESC, COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,21 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, created_at, updated_at, user_id, token_hash, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: GetUserBySession :one
+SELECT users.*
+FROM sessions
+INNER JOIN users ON sessions.user_id = users.id
+WHERE sessions.token_hash = $1
+AND (sessions.expires_at IS NULL OR sessions.expires_at > NOW());
+
+-- name: DeleteSessionsForUser :exec
+DELETE FROM sessions
+WHERE user_id = $1;
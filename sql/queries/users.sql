@@ -1,10 +1,11 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, password_hash)
 VALUES (
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
 RETURNING *;
 
@@ -18,6 +19,11 @@ DELETE FROM users;
 -- name: GetAllUsers :many
 SELECT * FROM users;
 
+-- name: SetUserPassword :exec
+UPDATE users
+SET updated_at = $2, password_hash = $3
+WHERE id = $1;
+
 -- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES (
@@ -43,20 +49,21 @@ WHERE ID = $1;
 
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, title)
     VALUES (
         $1,
         $2,
         $3,
         $4,
-        $5
+        $5,
+        $6
     )
     RETURNING *
 )
 
 SELECT
     inserted_feed_follow.*,
-    feeds.name AS feed_name,
+    COALESCE(inserted_feed_follow.title, feeds.title, feeds.name) AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
 INNER JOIN users ON inserted_feed_follow.user_id = users.id
@@ -67,10 +74,21 @@ SELECT * FROM feeds
 WHERE url = $1;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, users.name AS user_name, feeds.name AS feed_name FROM feed_follows
+SELECT feed_follows.*, users.name AS user_name, COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name, folders.name AS folder_name,
+    feeds.url AS feed_url, feeds.site_link,
+    (
+        SELECT COUNT(*) FROM posts
+        WHERE posts.feed_id = feed_follows.feed_id AND NOT EXISTS (
+            SELECT 1 FROM post_reads
+            WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+        )
+    ) AS unread_count
+FROM feed_follows
 INNER JOIN users ON feed_follows.user_id = users.id
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE feed_follows.user_id = $1;
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS LAST, feed_name;
 
 -- name: DeleteFeedFollows :exec
 DELETE FROM feed_follows
@@ -81,6 +99,11 @@ UPDATE feeds
 SET updated_at = $1, last_fetched_at = $2
 WHERE id = $3;
 
+-- name: RenameFeedFollow :execrows
+UPDATE feed_follows
+SET updated_at = $1, title = $2
+WHERE user_id = $3 AND feed_id = $4;
+
 -- name: GetNextFeedToFetch :one
 SELECT id FROM feeds
 ORDER BY last_fetched_at NULLS FIRST; 
@@ -89,8 +112,14 @@ ORDER BY last_fetched_at NULLS FIRST;
 SELECT * FROM feeds
 WHERE id = $1;
 
--- name: CreatePosts :exec
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
+-- name: GetFeedNameForUser :one
+SELECT COALESCE(feed_follows.title, feeds.title, feeds.name) AS feed_name
+FROM feeds
+LEFT JOIN feed_follows ON feed_follows.feed_id = feeds.id AND feed_follows.user_id = sqlc.arg('user_id')
+WHERE feeds.id = sqlc.arg('feed_id');
+
+-- name: CreatePosts :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, content, published_at, feed_id, episode, season, explicit, image_url)
 VALUES (
     $1,
     $2,
@@ -99,20 +128,93 @@ VALUES (
     $5,
     $6,
     $7,
-    $8
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
 )
-RETURNING *;
+ON CONFLICT (url) DO UPDATE
+SET updated_at = CASE
+        WHEN posts.title IS DISTINCT FROM EXCLUDED.title
+            OR posts.description IS DISTINCT FROM EXCLUDED.description
+            OR posts.content IS DISTINCT FROM EXCLUDED.content
+        THEN EXCLUDED.updated_at
+        ELSE posts.updated_at
+    END,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    episode = EXCLUDED.episode,
+    season = EXCLUDED.season,
+    explicit = EXCLUDED.explicit,
+    image_url = EXCLUDED.image_url
+RETURNING id;
 
 -- name: GetPostsForUser :many
 SELECT 
     posts.*, 
-    feeds.name as feed_name,
-    users.name as user_name
+    COALESCE(feed_follows.title, feeds.title, feeds.name) as feed_name,
+    users.name as user_name,
+    EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ) AS read,
+    EXISTS (
+        SELECT 1 FROM saved_posts
+        WHERE saved_posts.user_id = feed_follows.user_id AND saved_posts.post_id = posts.id
+    ) AS saved
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
 INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 INNER JOIN users ON feed_follows.user_id = users.id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.updated_at DESC 
-LIMIT $2;
-
+WHERE feed_follows.user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('folder')::text IS NULL OR feed_follows.folder_id IN (
+        SELECT folders.id FROM folders
+        WHERE folders.user_id = feed_follows.user_id AND folders.name = sqlc.narg('folder')::text
+    ))
+    AND (sqlc.narg('feed')::text IS NULL
+        OR feeds.url = sqlc.narg('feed')::text
+        OR feeds.name ILIKE sqlc.narg('feed')::text
+        OR feed_follows.title ILIKE sqlc.narg('feed')::text)
+    AND (sqlc.narg('author')::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_authors
+        INNER JOIN authors ON post_authors.author_id = authors.id
+        WHERE post_authors.post_id = posts.id
+            AND authors.name ILIKE '%' || sqlc.narg('author')::text || '%'
+    ))
+    AND (sqlc.narg('category')::text IS NULL OR EXISTS (
+        SELECT 1 FROM post_categories
+        INNER JOIN categories ON post_categories.category_id = categories.id
+        WHERE post_categories.post_id = posts.id
+            AND lower(categories.name) = lower(sqlc.narg('category')::text)
+    ))
+    AND (sqlc.narg('keyword')::text IS NULL
+        OR posts.title ILIKE '%' || sqlc.narg('keyword')::text || '%'
+        OR posts.description ILIKE '%' || sqlc.narg('keyword')::text || '%'
+        OR posts.content ILIKE '%' || sqlc.narg('keyword')::text || '%')
+    AND (sqlc.narg('search')::text IS NULL OR posts.search_vector @@ websearch_to_tsquery('english', sqlc.narg('search')::text))
+    AND (sqlc.narg('since')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg('since')::timestamp)
+    AND (sqlc.narg('until')::timestamp IS NULL OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg('until')::timestamp)
+    AND (sqlc.narg('read')::boolean IS NULL OR sqlc.narg('read')::boolean = EXISTS (
+        SELECT 1 FROM post_reads
+        WHERE post_reads.user_id = feed_follows.user_id AND post_reads.post_id = posts.id
+    ))
+    AND (sqlc.narg('after_date')::timestamp IS NULL
+        OR (COALESCE(posts.published_at, posts.created_at), posts.id) < (sqlc.narg('after_date')::timestamp, sqlc.narg('after_id')::uuid))
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateFeedMetadata :exec
+UPDATE feeds
+SET updated_at = $2,
+    title = $3,
+    site_link = $4,
+    description = $5,
+    language = $6,
+    image_url = $7,
+    generator = $8,
+    copyright = $9,
+    last_build_date = $10
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN title TEXT,
+ADD COLUMN site_link TEXT,
+ADD COLUMN description TEXT,
+ADD COLUMN language TEXT,
+ADD COLUMN image_url TEXT,
+ADD COLUMN generator TEXT,
+ADD COLUMN copyright TEXT,
+ADD COLUMN last_build_date TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN title,
+DROP COLUMN site_link,
+DROP COLUMN description,
+DROP COLUMN language,
+DROP COLUMN image_url,
+DROP COLUMN generator,
+DROP COLUMN copyright,
+DROP COLUMN last_build_date;
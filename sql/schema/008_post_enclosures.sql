@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN episode INTEGER,
+ADD COLUMN season INTEGER,
+ADD COLUMN explicit BOOLEAN,
+ADD COLUMN image_url TEXT;
+
+CREATE TABLE post_enclosures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    post_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length BIGINT,
+    duration INTEGER,
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    CONSTRAINT post_enclosures_post_url_unique UNIQUE(post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+
+ALTER TABLE posts
+DROP COLUMN episode,
+DROP COLUMN season,
+DROP COLUMN explicit,
+DROP COLUMN image_url;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE post_enclosures
+ADD COLUMN local_path TEXT,
+ADD COLUMN local_size BIGINT,
+ADD COLUMN downloaded_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE post_enclosures
+DROP COLUMN local_path,
+DROP COLUMN local_size,
+DROP COLUMN downloaded_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX posts_short_id_idx ON posts (replace(id::text, '-', '') text_pattern_ops);
+
+-- +goose Down
+DROP INDEX posts_short_id_idx;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX posts_published_idx ON posts ((COALESCE(published_at, created_at)) DESC, id DESC);
+
+-- +goose Down
+DROP INDEX posts_published_idx;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD COLUMN title TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN title;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN item_id BIGSERIAL UNIQUE;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN item_id;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN fever_api_key TEXT UNIQUE;
+
+ALTER TABLE feeds
+ADD COLUMN fever_id BIGSERIAL UNIQUE;
+
+ALTER TABLE folders
+ADD COLUMN fever_id BIGSERIAL UNIQUE;
+
+-- +goose Down
+ALTER TABLE folders DROP COLUMN fever_id;
+ALTER TABLE feeds DROP COLUMN fever_id;
+ALTER TABLE users DROP COLUMN fever_api_key;
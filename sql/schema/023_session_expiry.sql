@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE sessions
+ADD COLUMN expires_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN expires_at;
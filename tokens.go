@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"time"
+
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Read tokens can only get things from the API, write tokens can also
+// follow feeds and change read and starred state
+const (
+	scopeRead  = "read"
+	scopeWrite = "write"
+)
+
+// Tokens start with this so they're easy to spot in config files and logs
+const tokenPrefix = "gator_"
+
+// API tokens let other programs use the serve API as the user
+func handlerToken(s *state, cmd command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("No token command provided, use create, list or revoke")
+	}
+
+	args := cmd.args[1:]
+	switch cmd.args[0] {
+	case "create":
+		return createToken(s, user, args)
+	case "list":
+		return listTokens(s, user)
+	case "revoke":
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: token revoke [name]")
+		}
+		return revokeToken(s, user, args[0])
+	}
+	return fmt.Errorf("Unknown token command %v, use create, list or revoke", cmd.args[0])
+}
+
+// Only the hash is stored, so the token is shown this one time
+func createToken(s *state, user database.User, args []string) error {
+	ctx := context.Background()
+
+	createCmd := flag.NewFlagSet("token create", flag.ExitOnError)
+	scope := createCmd.String("scope", scopeRead, "What the token can do, read or write")
+	args = parseFlags(createCmd, args)
+
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: token create [name] --scope read|write")
+	}
+	if *scope != scopeRead && *scope != scopeWrite {
+		return fmt.Errorf("Unknown scope %v, use read or write", *scope)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		fmt.Println("Error generating token")
+		return err
+	}
+	_, err = s.db.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    user.ID,
+		Name:      args[0],
+		TokenHash: hashToken(token),
+		Scope:     *scope,
+	})
+	if err != nil {
+		fmt.Println("Error creating token")
+		return err
+	}
+
+	fmt.Printf("Created %v token %v, it won't be shown again:\n", *scope, args[0])
+	fmt.Println(token)
+	return nil
+}
+
+func listTokens(s *state, user database.User) error {
+	ctx := context.Background()
+
+	tokens, err := s.db.GetAPITokensForUser(ctx, user.ID)
+	if err != nil {
+		fmt.Println("Error getting tokens")
+		return err
+	}
+	if len(tokens) == 0 {
+		fmt.Println("No tokens found")
+		return nil
+	}
+	for _, token := range tokens {
+		lastUsed := "never used"
+		if token.LastUsedAt.Valid {
+			lastUsed = "last used " + token.LastUsedAt.Time.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%v (%v, created %v, %v)\n", token.Name, token.Scope, token.CreatedAt.Format("2006-01-02"), lastUsed)
+	}
+	return nil
+}
+
+func revokeToken(s *state, user database.User, name string) error {
+	ctx := context.Background()
+
+	removed, err := s.db.DeleteAPIToken(ctx, database.DeleteAPITokenParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		fmt.Println("Error revoking token")
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("No token called %v", name)
+	}
+	fmt.Printf("Revoked token %v\n", name)
+	return nil
+}
+
+// Used for login sessions as well as API tokens
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+// Tokens are random enough that a plain hash is safe to look them up by
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}